@@ -0,0 +1,60 @@
+package cfd1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// QueryHash returns the hash of sql used by [WithQueryAllowlist], as a
+// hexadecimal SHA-256 digest. The hash is computed from the query's normalized
+// form, in which literal values and placeholders are replaced with ?, keywords
+// and unquoted identifiers are uppercased, and whitespace and comments are
+// removed, so queries differing only in their values, formatting, or the number
+// of values in a list such as IN (?, ?, ?), have the same hash. Queries
+// differing in structure, such as an added condition or statement, do not.
+//
+// QueryHash can be used at build time to generate the allowlist from the
+// queries in a codebase:
+//
+//	for _, q := range queries {
+//	    fmt.Printf("%q,\n", cfd1.QueryHash(q))
+//	}
+func QueryHash(sql string) string {
+	sum := sha256.Sum256([]byte(normalizeSQL(sql)))
+	return hex.EncodeToString(sum[:])
+}
+
+// WithQueryAllowlist restricts the client to queries whose [QueryHash] is one
+// of hashes. Any other query is rejected with an error wrapping
+// [ErrQueryNotAllowed], without being sent. This is intended as a defense in
+// depth for services whose set of queries is fixed when they are deployed.
+// Using the option more than once allows the hashes given to each.
+//
+// The allowlist applies to every query the client sends, including those made
+// by methods of [Handle] and by the database/sql driver. The hashes of queries
+// made by the package itself must also be allowed if they are used, such as
+// "SELECT 1", sent by [Client.Ping]. It does not apply to API requests that are
+// not queries, such as listing databases, imports, and exports.
+func WithQueryAllowlist(hashes []string) ClientOption {
+	return func(c *Client) {
+		if c.queryAllowlist == nil {
+			c.queryAllowlist = make(map[string]bool, len(hashes))
+		}
+		for _, h := range hashes {
+			c.queryAllowlist[h] = true
+		}
+	}
+}
+
+// checkAllowlist returns an error wrapping ErrQueryNotAllowed if the client has
+// a query allowlist that does not include sql.
+func (c *Client) checkAllowlist(sql string) error {
+	if c.queryAllowlist == nil {
+		return nil
+	}
+	if hash := QueryHash(sql); !c.queryAllowlist[hash] {
+		return fmt.Errorf("%w: %s (hash %s)", ErrQueryNotAllowed, normalizeSQL(sql), hash)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cfd1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestQueryHash(t *testing.T) {
+	base := QueryHash("SELECT * FROM users WHERE id = ?")
+	tests := []struct {
+		name string
+		sql  string
+		same bool
+	}{
+		{"Different value", "SELECT * FROM users WHERE id = 42", true},
+		{"Formatting and case", "select *\n  from users -- by id\n  where id = :id", true},
+		{"Added condition", "SELECT * FROM users WHERE id = ? OR 1 = 1", false},
+		{"Added statement", "SELECT * FROM users WHERE id = ?; DROP TABLE users", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QueryHash(tt.sql) == base; got != tt.same {
+				t.Errorf("QueryHash(%q) == base: got %v, want %v", tt.sql, got, tt.same)
+			}
+		})
+	}
+	if len(base) != 64 {
+		t.Errorf("unexpected hash length: %d", len(base))
+	}
+}
+
+func TestQueryAllowlist(t *testing.T) {
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"})}, nil)
+	}, WithQueryAllowlist([]string{QueryHash("SELECT x FROM t WHERE id = ?")}))
+	ctx := context.Background()
+	h, _ := client.GetHandle(ctx, "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	if _, err := h.Query(ctx, "select x from t where id = ?", 1); err != nil {
+		t.Errorf("unexpected error for allowed query: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("unexpected number of requests: got %d, want 1", requests)
+	}
+
+	rejected := map[string]func() error{
+		"Query":         func() error { _, err := h.Query(ctx, "SELECT x FROM t"); return err },
+		"Batch":         func() error { _, err := h.Batch(ctx, []Statement{{SQL: "DELETE FROM t"}}); return err },
+		"QueryRawBytes": func() error { _, err := h.QueryRawBytes(ctx, "SELECT y FROM t WHERE id = ?", 1); return err },
+	}
+	for name, fn := range rejected {
+		if err := fn(); !errors.Is(err, ErrQueryNotAllowed) {
+			t.Errorf("%s: expected ErrQueryNotAllowed, got %v", name, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("rejected queries were sent: got %d requests, want 1", requests)
+	}
+}
@@ -0,0 +1,107 @@
+package cfd1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Statement is a single SQL statement with its own parameters, for use with
+// [Handle.Batch].
+type Statement struct {
+	SQL    string
+	Params []any
+}
+
+// Batch executes statements on this database in a single request, and returns
+// a [QueryResult] for each, in the same order. Unlike a semicolon-separated
+// query passed to [Handle.Query], each statement has its own parameters, which
+// may use any of SQLite's placeholder styles, or be named as with [Named].
+//
+// The statements are sent as a batch, which D1 executes in order as a single
+// transaction: if one fails, the changes made by the others are rolled back.
+// Each statement is subject to the D1 API's limit of 100 parameters per query,
+// and should hold a single SQL statement, so that it produces one result. The
+// rows read and written by every statement are added to the counters of the
+// handle and its client.
+//
+// Example usage:
+//
+//	results, err := h.Batch(ctx, []cfd1.Statement{
+//	    {SQL: "INSERT INTO users (name) VALUES (?)", Params: []any{"alice"}},
+//	    {SQL: "INSERT INTO users (name) VALUES (?)", Params: []any{"bob"}},
+//	})
+func (h *Handle) Batch(ctx context.Context, statements []Statement) ([]QueryResult, error) {
+	if len(statements) == 0 {
+		return nil, nil
+	}
+	for i, stmt := range statements {
+		if err := h.checkReadOnly(stmt.SQL); err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i, err)
+		}
+	}
+
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+	raw, err := h.client.rawBatch(ctx, h.dbID, statements)
+	if err != nil {
+		return nil, h.queryError(err)
+	}
+	h.recordMeta(resultMetas(raw)...)
+
+	results := make([]QueryResult, len(raw))
+	for i := range raw {
+		results[i] = raw[i].toQueryResult(h.client.disambiguateCols)
+		if err := h.client.transformResult(&results[i]); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// rawBatch executes statements on a database as a batch, in a single request
+// to the raw API, and returns the result of each statement, in order.
+func (c *Client) rawBatch(ctx context.Context, databaseID string, statements []Statement) ([]RawQueryResult, error) {
+	if c.maxResultSets > 0 && len(statements) > c.maxResultSets {
+		return nil, fmt.Errorf("%w: batch has %d statements, maximum is %d", ErrTooManyResultSets, len(statements), c.maxResultSets)
+	}
+	prepared := make([]Statement, len(statements))
+	batch := make([]map[string]any, len(statements))
+	for i, stmt := range statements {
+		p, err := c.prepareQuery(ctx, strings.TrimSpace(stmt.SQL), stmt.Params)
+		if err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i, err)
+		}
+		sql := c.limitRows(c.defaultLimit(ctx, p.SQL))
+		if err := checkQuerySize(sql); err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i, err)
+		}
+		prepared[i] = p
+		batch[i] = map[string]any{
+			"sql":    sql,
+			"params": p.Params,
+		}
+	}
+
+	var result []RawQueryResult
+	body := map[string]any{"batch": batch}
+	err := c.sendRequest(ctx, http.MethodPost, fmt.Sprintf("/database/%s/raw", databaseID), body, &result, nil)
+	if err != nil {
+		// The API does not report which statement failed.
+		sqls := make([]string, len(prepared))
+		var params []any
+		for i, p := range prepared {
+			sqls[i] = p.SQL
+			params = append(params, p.Params...)
+		}
+		return nil, c.queryError(err, strings.Join(sqls, ";\n"), params)
+	}
+	if len(result) != len(statements) {
+		return nil, fmt.Errorf("batch of %d statements returned %d results", len(statements), len(result))
+	}
+	if err := c.checkResults(databaseID, result, func(i int) Statement { return prepared[i] }); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// batchRequest is the body of a batch request to the raw API.
+type batchRequest struct {
+	Batch []rawQueryRequest `json:"batch"`
+}
+
+func TestHandleBatch(t *testing.T) {
+	var req batchRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		results := []RawQueryResult{
+			rawResult([]string{}),
+			rawResult([]string{"id"}, []any{7.0}),
+			rawResult([]string{"n"}, []any{1.0}),
+		}
+		results[0].Meta = QueryMeta{RowsWritten: 2}
+		results[1].Meta = QueryMeta{RowsRead: 1}
+		results[2].Meta = QueryMeta{RowsRead: 3}
+		writeAPIResult(w, results, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	results, err := h.Batch(context.Background(), []Statement{
+		{SQL: "INSERT INTO users (name) VALUES (?);", Params: []any{"alice"}},
+		{SQL: "SELECT id FROM users WHERE name = ?", Params: []any{"alice"}},
+		{SQL: "SELECT count(*) AS n FROM users WHERE active = :active", Params: []any{Named("active", true)}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := batchRequest{Batch: []rawQueryRequest{
+		{SQL: "INSERT INTO users (name) VALUES (?);", Params: []any{"alice"}},
+		{SQL: "SELECT id FROM users WHERE name = ?", Params: []any{"alice"}},
+		{SQL: "SELECT count(*) AS n FROM users WHERE active = ?1", Params: []any{1.0}},
+	}}
+	if !reflect.DeepEqual(req, want) {
+		t.Errorf("unexpected request: got %+v, want %+v", req, want)
+	}
+	if len(results) != 3 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	if want := []map[string]any{{"id": int64(7)}}; !reflect.DeepEqual(results[1].Results, want) {
+		t.Errorf("unexpected results: got %v, want %v", results[1].Results, want)
+	}
+	if h.RowsRead() != 4 || h.RowsWritten() != 2 {
+		t.Errorf("unexpected handle counters: %d read, %d written; want 4, 2", h.RowsRead(), h.RowsWritten())
+	}
+	if client.RowsRead() != 4 || client.RowsWritten() != 2 {
+		t.Errorf("unexpected client counters: %d read, %d written; want 4, 2", client.RowsRead(), client.RowsWritten())
+	}
+}
+
+func TestHandleBatchDuplicateColumns(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"id", "id"}, []any{1.0, 2.0})}, nil)
+	}
+	stmts := []Statement{{SQL: "SELECT u.id, o.id FROM users u JOIN orders o ON o.user_id = u.id"}}
+
+	// Like Query, Batch keeps the last of the columns sharing a name unless
+	// the client disambiguates them
+	for _, tt := range []struct {
+		opts []ClientOption
+		want map[string]any
+	}{
+		{nil, map[string]any{"id": int64(2)}},
+		{[]ClientOption{WithDisambiguatedColumns()}, map[string]any{"id": int64(1), "id_2": int64(2)}},
+	} {
+		client := newTestClient(t, handler, tt.opts...)
+		h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+		results, err := h.Batch(context.Background(), stmts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(results[0].Results, []map[string]any{tt.want}) {
+			t.Errorf("got %v, want %v", results[0].Results, tt.want)
+		}
+	}
+}
+
+func TestHandleBatchErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []ClientOption
+		statements []Statement
+		results    int // the number of results returned by the server
+		wantErr    error
+	}{
+		{"Too many statements", []ClientOption{WithMaxResultSets(1)},
+			[]Statement{{SQL: "SELECT 1"}, {SQL: "SELECT 2"}}, 2, ErrTooManyResultSets},
+		{"Too many params", nil,
+			[]Statement{{SQL: "SELECT ?", Params: make([]any, 101)}}, 1, ErrTooManyParams},
+		{"Mixed params", nil,
+			[]Statement{{SQL: "SELECT :a, ?", Params: []any{Named("a", 1), 2}}}, 1, ErrMixedParams},
+		{"Result count mismatch", nil,
+			[]Statement{{SQL: "SELECT 1; SELECT 2"}}, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				results := make([]RawQueryResult, tt.results)
+				writeAPIResult(w, results, nil)
+			}, tt.opts...)
+			h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+			_, err := h.Batch(context.Background(), tt.statements)
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
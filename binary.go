@@ -0,0 +1,229 @@
+package cfd1
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// binaryFormatVersion identifies the encoding produced by MarshalBinary, so
+// that data cached by an incompatible version is rejected.
+const binaryFormatVersion byte = 1
+
+// binaryValue kinds, identifying the Go type of an encoded value.
+const (
+	kindNil byte = iota
+	kindBool
+	kindInt64
+	kindFloat64
+	kindString
+	kindBytes
+	kindJSON // any other type, encoded as JSON
+)
+
+// binaryValue is a single result value in the binary encoding, tagged with its
+// kind so that it decodes to the same type.
+type binaryValue struct {
+	Kind  byte
+	Int   int64
+	Float float64
+	Str   string
+	Bytes []byte
+}
+
+// binaryResult is the binary encoding of a [QueryResult] or [RawQueryResult].
+// For a QueryResult, Columns holds the sorted keys of the first row, and Keys
+// holds the sorted keys of each row whose keys differ from Columns.
+type binaryResult struct {
+	Meta    QueryMeta
+	Success bool
+	Columns []string
+	Rows    [][]binaryValue
+	Keys    map[int][]string
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], encoding r in a compact
+// form suitable for caching, which preserves the types of the values in r.
+// Values of types other than nil, bool, int, int64, float64, string, and
+// []byte are encoded as JSON, and decode as the corresponding JSON types; ints
+// decode as int64.
+func (r QueryResult) MarshalBinary() ([]byte, error) {
+	br := binaryResult{Meta: r.Meta, Success: r.Success}
+	for i, row := range r.Results {
+		keys := make([]string, 0, len(row))
+		for k := range row {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		if i == 0 {
+			br.Columns = keys
+		} else if !slices.Equal(keys, br.Columns) {
+			if br.Keys == nil {
+				br.Keys = make(map[int][]string)
+			}
+			br.Keys[i] = keys
+		}
+
+		values := make([]binaryValue, len(keys))
+		for j, k := range keys {
+			bv, err := newBinaryValue(row[k])
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", k, err)
+			}
+			values[j] = bv
+		}
+		br.Rows = append(br.Rows, values)
+	}
+	return encodeBinaryResult(br)
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], decoding data
+// produced by [QueryResult.MarshalBinary] into r.
+func (r *QueryResult) UnmarshalBinary(data []byte) error {
+	br, err := decodeBinaryResult(data)
+	if err != nil {
+		return err
+	}
+
+	results := make([]map[string]any, len(br.Rows))
+	for i, values := range br.Rows {
+		keys := br.Columns
+		if k, ok := br.Keys[i]; ok {
+			keys = k
+		}
+		if len(keys) != len(values) {
+			return errors.New("invalid binary result: row length mismatch")
+		}
+		row := make(map[string]any, len(keys))
+		for j, k := range keys {
+			if row[k], err = values[j].value(); err != nil {
+				return fmt.Errorf("column %q: %w", k, err)
+			}
+		}
+		results[i] = row
+	}
+	*r = QueryResult{Meta: br.Meta, Results: results, Success: br.Success}
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], encoding r in a compact
+// form suitable for caching, as with [QueryResult.MarshalBinary].
+func (r RawQueryResult) MarshalBinary() ([]byte, error) {
+	br := binaryResult{Meta: r.Meta, Success: r.Success, Columns: r.Results.Columns}
+	br.Rows = make([][]binaryValue, len(r.Results.Rows))
+	for i, row := range r.Results.Rows {
+		values := make([]binaryValue, len(row))
+		for j, v := range row {
+			bv, err := newBinaryValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %d: %w", i, j, err)
+			}
+			values[j] = bv
+		}
+		br.Rows[i] = values
+	}
+	return encodeBinaryResult(br)
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], decoding data
+// produced by [RawQueryResult.MarshalBinary] into r.
+func (r *RawQueryResult) UnmarshalBinary(data []byte) error {
+	br, err := decodeBinaryResult(data)
+	if err != nil {
+		return err
+	}
+
+	var result RawQueryResult
+	result.Meta = br.Meta
+	result.Success = br.Success
+	result.Results.Columns = br.Columns
+	result.Results.Rows = make([][]any, len(br.Rows))
+	for i, values := range br.Rows {
+		row := make([]any, len(values))
+		for j, bv := range values {
+			if row[j], err = bv.value(); err != nil {
+				return fmt.Errorf("row %d, column %d: %w", i, j, err)
+			}
+		}
+		result.Results.Rows[i] = row
+	}
+	*r = result
+	return nil
+}
+
+// encodeBinaryResult encodes br, preceded by the format version.
+func encodeBinaryResult(br binaryResult) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(binaryFormatVersion)
+	if err := gob.NewEncoder(&buf).Encode(br); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBinaryResult decodes data produced by encodeBinaryResult.
+func decodeBinaryResult(data []byte) (binaryResult, error) {
+	var br binaryResult
+	if len(data) == 0 || data[0] != binaryFormatVersion {
+		return br, errors.New("invalid binary result: unsupported format version")
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&br); err != nil {
+		return br, fmt.Errorf("invalid binary result: %w", err)
+	}
+	return br, nil
+}
+
+// newBinaryValue returns the binary encoding of v.
+func newBinaryValue(v any) (binaryValue, error) {
+	switch v := v.(type) {
+	case nil:
+		return binaryValue{Kind: kindNil}, nil
+	case bool:
+		bv := binaryValue{Kind: kindBool}
+		if v {
+			bv.Int = 1
+		}
+		return bv, nil
+	case int:
+		return binaryValue{Kind: kindInt64, Int: int64(v)}, nil
+	case int64:
+		return binaryValue{Kind: kindInt64, Int: v}, nil
+	case float64:
+		return binaryValue{Kind: kindFloat64, Float: v}, nil
+	case string:
+		return binaryValue{Kind: kindString, Str: v}, nil
+	case []byte:
+		return binaryValue{Kind: kindBytes, Bytes: v}, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return binaryValue{}, err
+	}
+	return binaryValue{Kind: kindJSON, Bytes: b}, nil
+}
+
+// value returns the value encoded by bv.
+func (bv binaryValue) value() (any, error) {
+	switch bv.Kind {
+	case kindNil:
+		return nil, nil
+	case kindBool:
+		return bv.Int != 0, nil
+	case kindInt64:
+		return bv.Int, nil
+	case kindFloat64:
+		return bv.Float, nil
+	case kindString:
+		return bv.Str, nil
+	case kindBytes:
+		return bv.Bytes, nil
+	case kindJSON:
+		var v any
+		err := json.Unmarshal(bv.Bytes, &v)
+		return v, err
+	}
+	return nil, fmt.Errorf("invalid binary value kind %d", bv.Kind)
+}
@@ -0,0 +1,50 @@
+package cfd1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryResultBinary(t *testing.T) {
+	in := QueryResult{
+		Meta:    QueryMeta{RowsRead: 2, Duration: 0.5},
+		Success: true,
+		Results: []map[string]any{
+			{"id": 1.0, "name": "alice", "big": int64(1) << 60, "flag": true, "data": []byte{0, 1}, "note": nil},
+			{"id": 2.0, "tags": []any{"a", "b"}},
+		},
+	}
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out QueryResult
+	if err := out.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip mismatch:\ngot  %#v\nwant %#v", out, in)
+	}
+}
+
+func TestRawQueryResultBinary(t *testing.T) {
+	in := rawResult([]string{"id", "name", "score"},
+		[]any{1.0, "alice", int64(42)},
+		[]any{2.0, nil, 3.5},
+	)
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out RawQueryResult
+	if err := out.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip mismatch:\ngot  %#v\nwant %#v", out, in)
+	}
+
+	if err := out.UnmarshalBinary([]byte{99}); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
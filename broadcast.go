@@ -0,0 +1,132 @@
+package cfd1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// defaultBroadcastConcurrency is the number of databases that
+// [Client.BroadcastExec] runs its statement on at once, by default.
+const defaultBroadcastConcurrency = 4
+
+// WithBroadcastConcurrency sets the maximum number of databases that
+// [Client.BroadcastExec] runs its statement on at once. The default is 4.
+// Requests share the client's HTTP connection pool, so n should not exceed its
+// idle connection limit.
+func WithBroadcastConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		c.broadcastConcurrency = n
+	}
+}
+
+// WithBroadcastFailFast makes [Client.BroadcastExec] stop at the first database
+// on which its statement fails. Requests already in progress are canceled, and
+// databases not yet started are skipped. By default, the statement is run on
+// every database regardless of failures on others.
+func WithBroadcastFailFast() ClientOption {
+	return func(c *Client) {
+		c.broadcastFailFast = true
+	}
+}
+
+// BroadcastError is returned by [Client.BroadcastExec] if its statement failed
+// on any of the databases. Errs holds the error for each of DatabaseIDs, in the
+// same order, and is nil for those on which the statement succeeded. With
+// [WithBroadcastFailFast], databases that were skipped or canceled after the
+// first failure have the context's error.
+type BroadcastError struct {
+	DatabaseIDs []string
+	Errs        []error
+}
+
+func (e *BroadcastError) Error() string {
+	failed := e.Unwrap()
+	i := slices.IndexFunc(e.Errs, func(err error) bool { return err != nil })
+	return fmt.Sprintf("statement failed on %d of %d databases; %s: %v",
+		len(failed), len(e.DatabaseIDs), e.DatabaseIDs[i], e.Errs[i])
+}
+
+// Unwrap returns the errors of the databases on which the statement failed, so
+// that errors.Is and errors.As match any of them.
+func (e *BroadcastError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errs {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// BroadcastExec executes sql, with params, on each of the databases in
+// databaseIDs, such as the shards of a sharded dataset, and returns the
+// [QueryMeta] of each execution, in the same order. The databases are queried
+// concurrently, up to 4 at a time unless set otherwise with
+// [WithBroadcastConcurrency]. Each execution is a separate request, so there
+// is no atomicity across databases.
+//
+// If the statement fails on any database, a [*BroadcastError] reporting the
+// error for each database is returned, along with the metadata, which is zero
+// for the databases that failed. By default, a failure does not stop the
+// statement being run on the remaining databases; see [WithBroadcastFailFast].
+//
+// Example usage:
+//
+//	metas, err := client.BroadcastExec(ctx, shardIDs, "DELETE FROM sessions WHERE expires < ?", now)
+//	var bErr *cfd1.BroadcastError
+//	if errors.As(err, &bErr) {
+//	    for i, err := range bErr.Errs {
+//	        if err != nil {
+//	            log.Printf("shard %s: %v", bErr.DatabaseIDs[i], err)
+//	        }
+//	    }
+//	}
+func (c *Client) BroadcastExec(ctx context.Context, databaseIDs []string, sql string, params ...any) ([]QueryMeta, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := c.broadcastConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBroadcastConcurrency
+	}
+	metas := make([]QueryMeta, len(databaseIDs))
+	errs := make([]error, len(databaseIDs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+
+	// The databases are handed to the workers in order, so that with
+	// WithBroadcastFailFast, those after a failure are skipped.
+	for range min(concurrency, len(databaseIDs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				result, err := c.Query(ctx, databaseIDs[i], sql, params...)
+				if err != nil {
+					errs[i] = err
+					if c.broadcastFailFast {
+						cancel()
+					}
+					continue
+				}
+				metas[i] = result.Meta
+			}
+		}()
+	}
+	for i := range databaseIDs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if slices.ContainsFunc(errs, func(err error) bool { return err != nil }) {
+		return metas, &BroadcastError{DatabaseIDs: databaseIDs, Errs: errs}
+	}
+	return metas, nil
+}
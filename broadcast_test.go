@@ -0,0 +1,86 @@
+package cfd1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBroadcastExec(t *testing.T) {
+	ids := []string{"db-1", "db-2", "db-3", "db-4", "db-5"}
+	tests := []struct {
+		name       string
+		opts       []ClientOption
+		wantMetas  []QueryMeta
+		wantFailed []string // databases whose error is not a context error
+	}{
+		{
+			name:       "Partial failure",
+			wantMetas:  []QueryMeta{{Changes: 1}, {}, {Changes: 3}, {Changes: 4}, {Changes: 5}},
+			wantFailed: []string{"db-2"},
+		},
+		{
+			name:       "Fail fast",
+			opts:       []ClientOption{WithBroadcastConcurrency(1), WithBroadcastFailFast()},
+			wantMetas:  []QueryMeta{{Changes: 1}, {}, {}, {}, {}},
+			wantFailed: []string{"db-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var queried []string
+			var active, maxActive atomic.Int32
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				n := active.Add(1)
+				defer active.Add(-1)
+				for m := maxActive.Load(); n > m && !maxActive.CompareAndSwap(m, n); m = maxActive.Load() {
+				}
+				id := strings.Split(r.URL.Path, "/")[5]
+				mu.Lock()
+				queried = append(queried, id)
+				mu.Unlock()
+				if id == "db-2" {
+					writeAPIError(w, http.StatusBadRequest, 7500, "no such table: t: SQLITE_ERROR")
+					return
+				}
+				rs := rawResult(nil)
+				rs.Meta.Changes = int(id[3] - '0')
+				writeAPIResult(w, []RawQueryResult{rs}, nil)
+			}, tt.opts...)
+
+			metas, err := client.BroadcastExec(context.Background(), ids, "DELETE FROM t WHERE x = ?", 1)
+			if !reflect.DeepEqual(metas, tt.wantMetas) {
+				t.Errorf("unexpected metas: got %v, want %v", metas, tt.wantMetas)
+			}
+			var bErr *BroadcastError
+			if !errors.As(err, &bErr) {
+				t.Fatalf("expected BroadcastError, got %v", err)
+			}
+			var failed []string
+			for i, err := range bErr.Errs {
+				if err != nil && !errors.Is(err, context.Canceled) {
+					failed = append(failed, bErr.DatabaseIDs[i])
+				}
+			}
+			if !reflect.DeepEqual(failed, tt.wantFailed) {
+				t.Errorf("unexpected failed databases: got %v, want %v", failed, tt.wantFailed)
+			}
+			var sqliteErr *SQLiteError
+			if !errors.As(err, &sqliteErr) {
+				t.Errorf("expected error to wrap SQLiteError, got %v", err)
+			}
+			if c := int(maxActive.Load()); c > 4 || (len(tt.opts) > 0 && c > 1) {
+				t.Errorf("too many concurrent requests: %d", c)
+			}
+			if tt.wantMetas[2].Changes == 0 && len(queried) != 2 {
+				t.Errorf("expected queries to stop after the failure, got %v", queried)
+			}
+		})
+	}
+}
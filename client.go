@@ -44,11 +44,29 @@ format:
 	db, err := sql.Open("cfd1",
 	    "d1://your-account-id:your-api-token@database-name-or-UUID")
 
-All three components of the DSN are required.
+All three components of the DSN are required. The client used by the driver can
+be configured with optional query parameters:
+
+  - timeout: the request timeout, as a duration such as "10s"; see
+    [WithRequestTimeout]
+  - endpoint: the API endpoint URL; see [WithEndpoint]
+  - max_concurrent: the maximum number of queries sent at once by all of the
+    connections of a sql.DB; further queries wait until one finishes, or their
+    context is done
+  - config: the name of a set of [ClientOption] values registered with
+    [RegisterConfig], which are applied before the other parameters
+
+For example:
+
+	cfd1.RegisterConfig("tuned", cfd1.WithListConcurrency(4))
+	db, err := sql.Open("cfd1",
+	    "d1://your-account-id:your-api-token@database-name-or-UUID?config=tuned&timeout=10s")
 
 Note that this driver does not support transactions through db.Begin(), as
 connections to D1 over the REST API are not persistent -- every query creates a
-new HTTP round-trip to the API and connection. Multiple semicolon-separated
+new HTTP round-trip to the API and connection. db.Begin() and db.BeginTx()
+return an error wrapping [ErrNotSupported], which tools probing for
+transaction support can detect with errors.Is. Multiple semicolon-separated
 statements in a single query are supported, however, and can include
 transactions.
 
@@ -69,10 +87,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"net/http/httptrace"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -83,6 +105,8 @@ const (
 	defaultHttpTimeout       = 30 * time.Second
 	defaultIdleConnTimeout   = 90 * time.Second
 	defaultMaxIdleConns      = 100
+	retryBaseDelay           = 250 * time.Millisecond
+	retryMaxDelay            = 30 * time.Second
 )
 
 // CFD1Client defines the interface for interacting with a CFD1 database. It
@@ -114,6 +138,39 @@ type Client struct {
 	rowsRead    int
 	rowsWritten int
 	mux         sync.RWMutex
+
+	listConcurrency      int
+	debugRedactor        DebugRedactor
+	bindingHints         bool
+	slowQueryThreshold   time.Duration
+	slowQueryHandler     SlowQueryHandler
+	utf8Handling         UTF8Handling
+	disambiguateCols     bool
+	implicitTx           bool
+	maxResultRows        int
+	requestTimeout       time.Duration
+	hasRequestTimeout    bool
+	columnTransformers   map[string]ColumnTransformer
+	resultTransformers   []ResultTransformer
+	rawTransformers      []RawResultTransformer
+	retryPredicate       RetryPredicate
+	retryDelay           time.Duration // initial delay between attempts, if not retryBaseDelay
+	scheduler            *scheduler
+	maxResultSets        int
+	detailsCache         *detailsCache
+	nPlusOne             *nPlusOneDetector
+	nPlusOneHandler      NPlusOneHandler
+	minOpDeadline        time.Duration
+	maxQueryParams       int
+	strictScan           bool
+	broadcastConcurrency int
+	broadcastFailFast    bool
+	connStats            bool
+	newConns             int
+	reusedConns          int
+	queryAllowlist       map[string]bool // by QueryHash; nil allows all queries
+	defaultLimitRows     int
+	streamPageRows       int
 }
 
 // ClientOption is a function type for configuring a Client.
@@ -135,8 +192,22 @@ type apiResponseInfo struct {
 	TotalCount int `json:"total_count"`
 }
 
+// responseInfo receives details of an API response other than its result.
+type responseInfo struct {
+	resultInfo  apiResponseInfo // pagination metadata
+	header      http.Header     // HTTP response headers
+	body        []byte          // response body, set even if the request failed
+	discardBody bool            // do not set body, as the caller does not use it
+}
+
+// hasMore reports whether there are more pages after the one described.
+func (i apiResponseInfo) hasMore() bool {
+	return i.Count > 0 && i.Page*i.PerPage < i.TotalCount
+}
+
 var (
-	regexUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-([0-9a-fA-F]{4}-){3}[0-9a-fA-F]{12}$`)
+	regexUUID         = regexp.MustCompile(`^[0-9a-fA-F]{8}-([0-9a-fA-F]{4}-){3}[0-9a-fA-F]{12}$`)
+	regexUUIDNoHyphen = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
 )
 
 // WithEndpoint sets a custom endpoint URL for the D1 client. The default
@@ -150,26 +221,437 @@ func WithEndpoint(url string) ClientOption {
 // WithHTTPClient sets a custom HTTP client for the D1 client. The default
 // client uses a 30 second timeout and maintains up to 100 max idle connections,
 // with a 90 second idle timeout. This option can be used to configure custom
-// timeouts, transport settings, or other client options.
+// timeouts, transport settings, or other client options. Multiple clients may
+// share an HTTP client, and with it a connection pool; see also [ClientPool].
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(c *Client) {
 		c.httpClient = httpClient
 	}
 }
 
+// WithRequestTimeout sets the time limit for each HTTP request made by the
+// client, including reading the response body. The default is 30 seconds. The
+// timeout is applied to a copy of the client's HTTP client after all options
+// have been applied, so it takes effect even with [WithHTTPClient], without
+// modifying a shared HTTP client. A timeout of zero means no timeout; deadlines
+// can still be set through the context passed to each method.
+func WithRequestTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.requestTimeout = d
+		c.hasRequestTimeout = true
+	}
+}
+
 // WithDebugLogger enables debug logging for the D1 client. The provided logger
 // is given copies of HTTP request and response bodies exchanged with the
-// Cloudflare D1 API for logging and inspection.
+// Cloudflare D1 API for logging and inspection. The client's HTTP client is
+// copied rather than modified, so an HTTP client shared with other clients
+// keeps its original transport.
 func WithDebugLogger(logger DebugLogger) ClientOption {
 	return func(c *Client) {
 		transport := c.httpClient.Transport
 		if transport == nil {
 			transport = http.DefaultTransport
 		}
+		httpClient := *c.httpClient
+		c.httpClient = &httpClient
 		c.httpClient.Transport = &debugTransport{
 			transport: transport,
 			logger:    logger,
+			redact: func(body []byte) []byte {
+				if c.debugRedactor == nil {
+					return body
+				}
+				return c.debugRedactor(body)
+			},
+		}
+	}
+}
+
+// WithDebugRedactor sets a [DebugRedactor] that is applied to request and
+// response bodies before they are passed to the logger configured with
+// [WithDebugLogger]. Pass [RedactQueryParams] to omit bound parameter values
+// from logs. The redactor has no effect unless a debug logger is also set.
+func WithDebugRedactor(redactor DebugRedactor) ClientOption {
+	return func(c *Client) {
+		c.debugRedactor = redactor
+	}
+}
+
+// WithListConcurrency sets the maximum number of pages that
+// [Client.ListDatabases] fetches concurrently. After the first page has been
+// retrieved, the total number of pages is known, and the remaining pages are
+// requested in parallel, up to n at a time. The default of 1 fetches pages
+// sequentially. Requests share the client's HTTP connection pool, so n should
+// not exceed its idle connection limit.
+func WithListConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		c.listConcurrency = n
+	}
+}
+
+// httpTimeoutKey is the context key for a timeout set with [WithHTTPTimeout].
+type httpTimeoutKey struct{}
+
+// WithHTTPTimeout returns a copy of ctx that limits each HTTP request made with
+// it to d, in place of the client's request timeout set with
+// [WithRequestTimeout] or [WithHTTPClient]. This allows a slow operation to be
+// given longer than the client's timeout, or a query to fail sooner, without
+// another client. The timeout applies to each request separately, including
+// each retry; use a context deadline to limit a call as a whole. A timeout of
+// zero means no timeout. A request that exceeds the timeout returns an error
+// wrapping [ErrCanceled] and context.DeadlineExceeded.
+//
+// Example usage:
+//
+//	ctx = cfd1.WithHTTPTimeout(ctx, 5*time.Minute)
+//	err := h.Execute(ctx, "UPDATE events SET archived = 1 WHERE created_at < ?", cutoff)
+func WithHTTPTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, httpTimeoutKey{}, d)
+}
+
+// WithBindingHints enables correlation of SQLite constraint failures with the
+// query bindings that likely caused them. When enabled, a [SQLiteError] for an
+// error such as "UNIQUE constraint failed: users.email" identifies the
+// placeholders bound to the email column, and includes their values in its
+// error string. Because the hint may include bound values, enable this option
+// only where those values are safe to appear in error messages and logs.
+func WithBindingHints() ClientOption {
+	return func(c *Client) {
+		c.bindingHints = true
+	}
+}
+
+// SlowQueryHandler is a function called for each query whose execution time, as
+// reported by the D1 API in [QueryMeta.Duration], exceeds the threshold set
+// with [WithSlowQueryThreshold]. It receives the query, its parameters, and the
+// metadata of the slow result. For a query containing multiple statements, it
+// is called once for each statement that exceeded the threshold. To keep
+// parameter values out of logs, pass params through [RedactParams].
+type SlowQueryHandler func(sql string, params []any, meta QueryMeta)
+
+// WithSlowQueryThreshold sets the execution time above which queries are
+// reported to the handler set with [WithSlowQueryHandler]. Slow queries are
+// not reported unless both options are set.
+func WithSlowQueryThreshold(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.slowQueryThreshold = d
+	}
+}
+
+// WithSlowQueryHandler sets a [SlowQueryHandler] to be called for queries that
+// exceed the threshold set with [WithSlowQueryThreshold]. The handler is
+// called synchronously before the query method returns, and may be called
+// concurrently if the client is used concurrently.
+func WithSlowQueryHandler(handler SlowQueryHandler) ClientOption {
+	return func(c *Client) {
+		c.slowQueryHandler = handler
+	}
+}
+
+// UTF8Handling specifies how a client handles queries and string parameters
+// that are not valid UTF-8. Queries and their parameters are sent to the D1 API
+// as JSON, which can only represent valid UTF-8 text, so invalid byte sequences
+// cannot be sent as-is. Binary data should be passed as a []byte parameter,
+// which is sent base64-encoded, or encoded by the caller, for example as hex
+// for use with SQLite's unhex() function.
+type UTF8Handling int
+
+const (
+	// UTF8Replace replaces each invalid byte in a string with the Unicode
+	// replacement character U+FFFD, as encoding/json does. The query is sent,
+	// but the stored text will differ from the original bytes. This is the
+	// default.
+	UTF8Replace UTF8Handling = iota
+
+	// UTF8Reject returns an error wrapping [ErrInvalidUTF8] that identifies the
+	// offending parameter, without sending the query.
+	UTF8Reject
+)
+
+// WithUTF8Handling sets how the client handles queries and string parameters
+// containing invalid UTF-8. The default is [UTF8Replace].
+func WithUTF8Handling(mode UTF8Handling) ClientOption {
+	return func(c *Client) {
+		c.utf8Handling = mode
+	}
+}
+
+// WithDisambiguatedColumns makes [Client.Query], [Handle.Query], and
+// [Handle.Batch] keep all columns of results with duplicate column names, such
+// as a join selecting the id column of two tables. By default, each row is returned as a map, so only
+// the last of the columns sharing a name is kept. With this option, repeated
+// column names are given a numeric suffix in the result maps: "id", "id_2",
+// "id_3", and so on, skipping any names already used by other columns.
+func WithDisambiguatedColumns() ClientOption {
+	return func(c *Client) {
+		c.disambiguateCols = true
+	}
+}
+
+// WithImplicitTransactions makes the client wrap each query that contains a
+// write statement in an explicit transaction, by adding BEGIN TRANSACTION and
+// COMMIT statements around it. Queries that only read, and queries that already
+// contain transaction control statements such as BEGIN or SAVEPOINT, are sent
+// unchanged. The results of the added statements are removed from the results
+// returned to the caller.
+//
+// Without this option, D1 uses SQLite's autocommit behavior: each statement
+// outside an explicit transaction is committed as soon as it completes, so if
+// a statement in a multi-statement query fails, the changes made by the
+// statements before it remain. With this option, the changes of all statements
+// in a query are committed together, or not at all.
+func WithImplicitTransactions() ClientOption {
+	return func(c *Client) {
+		c.implicitTx = true
+	}
+}
+
+// WithMaxResultRows limits the number of rows a query may return in each result
+// set to n, as a guard against accidentally processing unexpectedly large
+// results. A query that returns more rows fails with a [TooManyRowsError].
+//
+// To avoid downloading the complete result of an oversized query, a LIMIT
+// clause of n+1 is appended to queries that consist of a single SELECT, WITH,
+// or VALUES statement without a LIMIT clause of their own. Other queries are
+// sent unchanged, and checked after their results are received.
+func WithMaxResultRows(n int) ClientOption {
+	return func(c *Client) {
+		c.maxResultRows = n
+	}
+}
+
+// WithDefaultLimit appends a LIMIT n clause to each SELECT, WITH, or VALUES
+// statement of a query that does not have a LIMIT clause of its own, as a guard
+// against accidentally reading an entire large table, such as from an
+// interactive query console. Unlike [WithMaxResultRows], the rows beyond the
+// limit are silently omitted rather than reported as an error. LIMIT clauses
+// within subqueries are not considered, nor added, and statements that write
+// to the database are not changed. The limit can be bypassed for individual
+// calls with a context returned by [WithoutDefaultLimit].
+//
+// The clause is added by [Client.Query], [Client.RawQuery], [Handle.Batch],
+// and the methods that use them, but not [Handle.QueryRawBytes], which sends
+// its query as given.
+func WithDefaultLimit(n int) ClientOption {
+	return func(c *Client) {
+		c.defaultLimitRows = n
+	}
+}
+
+// ColumnTransformer converts a value read from a column, such as an integer
+// storing an enum, into the value to return in its place. It is not called for
+// NULL values.
+type ColumnTransformer func(value any) (any, error)
+
+// WithColumnTransformer registers fn to transform the values of every result
+// column named column, in the results of all queries made by the client. The
+// transformed values are returned in result maps and raw results, and are what
+// Scan and ScanStruct convert into their destinations. If fn returns an error,
+// the query returns it. Registering a column again replaces its transformer.
+//
+// Example usage:
+//
+//	statusNames := map[int64]string{0: "pending", 1: "active", 2: "closed"}
+//	client := cfd1.NewClient(accountID, apiToken,
+//	    cfd1.WithColumnTransformer("status", func(v any) (any, error) {
+//	        if name, ok := statusNames[v.(int64)]; ok {
+//	            return name, nil
+//	        }
+//	        return nil, fmt.Errorf("unknown status %v", v)
+//	    }))
+func WithColumnTransformer(column string, fn ColumnTransformer) ClientOption {
+	return func(c *Client) {
+		if c.columnTransformers == nil {
+			c.columnTransformers = make(map[string]ColumnTransformer)
 		}
+		c.columnTransformers[column] = fn
+	}
+}
+
+// ResultTransformer modifies a query result in place, such as to rename or
+// remove columns. If it returns an error, the query returns it.
+type ResultTransformer func(result *QueryResult) error
+
+// RawResultTransformer modifies a raw query result in place, as a
+// [ResultTransformer] does for map results.
+type RawResultTransformer func(result *RawQueryResult) error
+
+// WithResultTransformer registers fn to transform the result of every query
+// made with [Client.Query] and [Handle.Query], and each result of
+// [Handle.Batch], after it is decoded and its column transformers, if any, are
+// applied. This allows results to be reshaped or redacted in one place, such as
+// by removing sensitive columns. A client may have several transformers, which
+// are run in the order they were registered.
+//
+// Example usage:
+//
+//	client := cfd1.NewClient(accountID, apiToken,
+//	    cfd1.WithResultTransformer(func(r *cfd1.QueryResult) error {
+//	        r.Columns = slices.DeleteFunc(r.Columns, func(c string) bool { return c == "password_hash" })
+//	        for _, row := range r.Results {
+//	            delete(row, "password_hash")
+//	        }
+//	        return nil
+//	    }))
+func WithResultTransformer(fn ResultTransformer) ClientOption {
+	return func(c *Client) {
+		c.resultTransformers = append(c.resultTransformers, fn)
+	}
+}
+
+// WithRawResultTransformer registers fn to transform each result set of every
+// query made with [Client.RawQuery], which includes queries whose rows are
+// scanned with [Row] and [Rows], and the queries behind [Client.Query], which
+// are transformed before being converted to maps. Transformers are run in the
+// order they were registered, after any column transformers.
+//
+// Raw transformers also see the queries the package makes internally, such as
+// those of [Handle.Indexes] and [Client.Schema], so they should leave results
+// they do not recognize unchanged.
+func WithRawResultTransformer(fn RawResultTransformer) ClientOption {
+	return func(c *Client) {
+		c.rawTransformers = append(c.rawTransformers, fn)
+	}
+}
+
+// RetryPredicate decides whether a failed request should be retried. It is
+// called with the error from the request, and the number of the attempt that
+// failed, starting from 1. Errors from queries that SQLite rejected are passed
+// as a [SQLiteError], so the predicate can inspect the SQLite error code.
+type RetryPredicate func(err error, attempt int) bool
+
+// WithRetryPredicate sets a [RetryPredicate] that decides whether failed
+// requests are retried. Retries are delayed with jittered exponential backoff,
+// starting at about 250ms and doubling up to 30s, unless the failed response
+// has a Retry-After header, whose delay is used instead, up to 30s. The
+// predicate is responsible for limiting the number of attempts; for example,
+// by returning false once attempt reaches a maximum. Retries stop if the
+// request's context is canceled, regardless of the predicate.
+//
+// Only requests that are safe to repeat are passed to the predicate: GET
+// requests, which read metadata, and queries whose statements only read from
+// the database. A query that may write is retried only when its error shows
+// that it was not applied: SQLite rejected it, as with SQLITE_BUSY, or the
+// request was rate limited with HTTP status 429. After a 5xx response or a
+// network error, a write may already have been applied, and repeating it could
+// apply it twice, so the error is returned. Other requests, such as creating a
+// database, are never retried.
+//
+// Example usage:
+//
+//	client := cfd1.NewClient(accountID, apiToken,
+//	    cfd1.WithRetryPredicate(func(err error, attempt int) bool {
+//	        var d1Err *cfd1.D1Error
+//	        return attempt < 3 && errors.As(err, &d1Err) && d1Err.Code == 7429
+//	    }))
+func WithRetryPredicate(fn RetryPredicate) ClientOption {
+	return func(c *Client) {
+		c.retryPredicate = fn
+	}
+}
+
+// WithRetry makes the client retry requests that fail with a transient error:
+// a response with HTTP status 429 (Too Many Requests) or a 5xx status. Each
+// request is attempted at most maxAttempts times, with delays between attempts
+// as described for [WithRetryPredicate], but starting at baseDelay. Only
+// requests that are safe to repeat, as described there, are retried: queries
+// that may write are retried after a 429 response, but not after a 5xx
+// response, since they may already have been applied. Other errors, including
+// other 4xx responses, are returned immediately. If every attempt fails, the
+// error from the last one is returned unchanged, so errors.As with a
+// [D1Error] reports its status code. WithRetry replaces any predicate set with
+// WithRetryPredicate.
+//
+// Example usage:
+//
+//	client := cfd1.NewClient(accountID, apiToken, cfd1.WithRetry(5, 500*time.Millisecond))
+func WithRetry(maxAttempts int, baseDelay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retryPredicate = func(err error, attempt int) bool {
+			return attempt < maxAttempts && isTransientError(err)
+		}
+		c.retryDelay = baseDelay
+	}
+}
+
+// isTransientError reports whether err is an API error that may succeed if
+// the request is repeated: a response with HTTP status 429 or 5xx.
+func isTransientError(err error) bool {
+	var d1Err *D1Error
+	return errors.As(err, &d1Err) &&
+		(d1Err.StatusCode == http.StatusTooManyRequests || d1Err.StatusCode >= 500)
+}
+
+// WithMaxResultSets limits the number of result sets a query may return to n,
+// protecting against unbounded memory use by a runaway multi-statement query.
+// Before a query is sent, its statements are counted, and a query with more
+// than n statements is rejected without being executed. If the API still
+// returns more than n result sets, the results are discarded. In both cases,
+// the query fails with an error wrapping [ErrTooManyResultSets].
+func WithMaxResultSets(n int) ClientOption {
+	return func(c *Client) {
+		c.maxResultSets = n
+	}
+}
+
+// WithMinOperationDeadline sets the minimum time that must remain before the
+// deadline of the context passed to an import or export for it to be started.
+// Imports and exports of large databases can take several minutes, and cannot
+// be canceled on the API server once started, so starting one that cannot
+// finish in time wastes the work and leaves the database unavailable to other
+// queries while it runs. If the context's deadline is sooner than d, the
+// operation fails immediately with an error wrapping [ErrDeadlineTooShort],
+// without making any API requests. Contexts without a deadline are not
+// affected. By default, there is no minimum.
+func WithMinOperationDeadline(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.minOpDeadline = d
+	}
+}
+
+// checkOperationDeadline returns an error wrapping ErrDeadlineTooShort if ctx
+// has a deadline sooner than the client's minimum for the named operation.
+func (c *Client) checkOperationDeadline(ctx context.Context, operation string) error {
+	if c.minOpDeadline <= 0 {
+		return nil
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	if remaining := time.Until(deadline); remaining < c.minOpDeadline {
+		return fmt.Errorf("%w for %s: %v remaining, minimum is %v",
+			ErrDeadlineTooShort, operation, remaining.Round(time.Millisecond), c.minOpDeadline)
+	}
+	return nil
+}
+
+// WithStrictScan makes scanning of query results by [Row], [Rows], and
+// [QueryIn] fail unless each value already has a type that fits its
+// destination, so that a column whose type changed unexpectedly is reported
+// rather than silently converted. Integers can be scanned into any integer or
+// floating-point destination they fit in, floating-point numbers into
+// floating-point destinations, and strings into string destinations; otherwise,
+// the value's type must be assignable to the destination's. Conversions such as
+// from strings to numbers, numbers to strings, or integers to time.Time are
+// not made. Types registered with [RegisterType] and destinations implementing
+// sql.Scanner still convert values as they choose, and json.RawMessage
+// destinations still receive the JSON document of a TEXT column.
+func WithStrictScan() ClientOption {
+	return func(c *Client) {
+		c.strictScan = true
+	}
+}
+
+// WithMaxQueryParams sets the maximum number of parameters a query may bind,
+// in place of [MaxQueryParams], for use if D1 changes its limit. Queries that
+// bind more are rejected with a [TooManyParamsError] without being sent, and
+// helpers that split their work into several queries, such as [QueryIn] and
+// [Handle.InsertMany], use the limit to size each query.
+func WithMaxQueryParams(n int) ClientOption {
+	return func(c *Client) {
+		c.maxQueryParams = n
 	}
 }
 
@@ -185,6 +667,11 @@ func NewClient(accountID string, apiToken string, options ...ClientOption) *Clie
 	for _, option := range options {
 		option(c)
 	}
+	if c.hasRequestTimeout {
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.requestTimeout
+		c.httpClient = &httpClient
+	}
 	return c
 }
 
@@ -205,7 +692,7 @@ func defaultHTTPClient() *http.Client {
 // reset.
 func (c *Client) RowsRead() int {
 	c.mux.RLock()
-	defer c.mux.Unlock()
+	defer c.mux.RUnlock()
 	return c.rowsRead
 }
 
@@ -213,16 +700,19 @@ func (c *Client) RowsRead() int {
 // last reset.
 func (c *Client) RowsWritten() int {
 	c.mux.RLock()
-	defer c.mux.Unlock()
+	defer c.mux.RUnlock()
 	return c.rowsWritten
 }
 
-// ResetCounters resets the client's internal row counters to zero.
+// ResetCounters resets the client's internal row counters to zero, along with
+// its connection counters; see [Client.Stats].
 func (c *Client) ResetCounters() {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 	c.rowsRead = 0
 	c.rowsWritten = 0
+	c.newConns = 0
+	c.reusedConns = 0
 }
 
 // GetHandle returns a new [Handle] for the specified database name or UUID. If
@@ -240,14 +730,36 @@ func (c *Client) GetHandle(ctx context.Context, dbNameOrUUID string) (*Handle, e
 	return &Handle{client: c, dbID: dbid}, nil
 }
 
+// Ping checks that the database identified by databaseID, which must be a UUID,
+// is reachable by running SELECT 1 against it. Unlike [Handle.Ping], it needs
+// no handle, so no name lookup is made. If the database does not exist, the
+// error wraps [ErrNotFound]; an invalid or unauthorized API token gives an
+// error wrapping [ErrPermissionDenied]; and other errors, such as network
+// failures, are returned as they occur.
+func (c *Client) Ping(ctx context.Context, databaseID string) error {
+	uuid, ok := normalizeUUID(strings.TrimSpace(databaseID))
+	if !ok {
+		return fmt.Errorf("pinging database: %q is not a database UUID", databaseID)
+	}
+	if _, err := c.RawQuery(ctx, uuid, "SELECT 1"); err != nil {
+		if isNotFoundError(err) {
+			return fmt.Errorf("pinging database: %w: %s: %w", ErrNotFound, uuid, err)
+		}
+		return fmt.Errorf("pinging database: %w", err)
+	}
+	return nil
+}
+
 // FindDatabase looks up a database UUID by name or UUID. If the input is
-// already a UUID, it is returned directly. If the input is a name, the database
+// already a UUID, it is returned directly in canonical form: lowercase, with
+// hyphens. Surrounding whitespace is ignored, and a UUID written as 32 hex
+// digits without hyphens is also accepted. If the input is a name, the database
 // is looked up via the API and its UUID is returned. ErrNotFound is returned if
 // the database does not exist.
 func (c *Client) FindDatabase(ctx context.Context, dbNameOrUUID string) (string, error) {
-	isUUID := regexUUID.MatchString(dbNameOrUUID)
-	if isUUID {
-		return dbNameOrUUID, nil
+	dbNameOrUUID = strings.TrimSpace(dbNameOrUUID)
+	if uuid, ok := normalizeUUID(dbNameOrUUID); ok {
+		return uuid, nil
 	}
 
 	dbs, err := c.ListDatabases(ctx, dbNameOrUUID)
@@ -263,19 +775,232 @@ func (c *Client) FindDatabase(ctx context.Context, dbNameOrUUID string) (string,
 	return "", fmt.Errorf("%w: %s", ErrNotFound, dbNameOrUUID)
 }
 
-// sendRequest sends an HTTP request to the Cloudflare API and processes the
-// response.
-func (c *Client) sendRequest(ctx context.Context, method, path string, body any, v any, pagInfo *apiResponseInfo) error {
-	url := fmt.Sprintf("%s/accounts/%s/d1/%s", c.baseURL, c.accountID, strings.TrimPrefix(path, "/"))
+// normalizeUUID returns s in canonical lowercase, hyphenated UUID form, and true
+// if s is a UUID with or without hyphens. Otherwise, it returns false.
+func normalizeUUID(s string) (string, bool) {
+	switch {
+	case regexUUID.MatchString(s):
+		return strings.ToLower(s), true
+	case regexUUIDNoHyphen.MatchString(s):
+		s = strings.ToLower(s)
+		return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32], true
+	}
+	return "", false
+}
+
+// Do sends a request to an arbitrary endpoint of the D1 API, for use with
+// features that this package does not yet wrap. The request is sent to path
+// relative to /accounts/{account_id}/d1/, such as "database/{uuid}/query",
+// with the client's authentication, and body, if non-nil, encoded as JSON. On
+// success, the result field of the response envelope is decoded into out, if
+// non-nil, as with encoding/json.Unmarshal. API errors are returned as with
+// other methods, such as a [D1Error] or an error wrapping
+// [ErrPermissionDenied].
+//
+// Do is a low-level escape hatch, not a stable interface: it is tied to the
+// details of the REST API, and queries sent with it bypass the client's query
+// features, such as row limits and the slow query handler. Prefer the
+// dedicated methods where they exist.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	return c.sendRequest(ctx, method, "/"+strings.TrimPrefix(path, "/"), body, out, nil)
+}
 
+// sendRequest sends an HTTP request to the Cloudflare API and processes the
+// response, retrying it if the client's retry policy allows.
+func (c *Client) sendRequest(ctx context.Context, method, path string, body any, v any, info *responseInfo) error {
 	var reqBytes []byte
-	var err error
 	if body != nil {
+		var err error
 		if reqBytes, err = json.Marshal(body); err != nil {
 			return fmt.Errorf("encoding request body: %w", err)
 		}
 	}
 
+	// Response headers are needed to honor Retry-After, even if the caller
+	// does not want them, but the body is kept only for a caller that does.
+	if info == nil {
+		info = &responseInfo{discardBody: true}
+	}
+	delay := retryBaseDelay
+	if c.retryDelay > 0 {
+		delay = c.retryDelay
+	}
+	for attempt := 1; ; attempt++ {
+		info.header = nil
+		if c.scheduler != nil {
+			if err := c.scheduler.acquire(ctx); err != nil {
+				return canceledError(method, path, err)
+			}
+		}
+		err := c.doRequest(ctx, method, path, reqBytes, v, info)
+		if c.scheduler != nil {
+			c.scheduler.release()
+		}
+		if err == nil || !c.shouldRetry(ctx, method, path, reqBytes, err, attempt) {
+			return err
+		}
+
+		wait := delay/2 + time.Duration(rand.Int64N(int64(delay/2)+1))
+		if d, ok := retryAfter(info.header); ok {
+			wait = min(d, retryMaxDelay)
+		}
+		select {
+		case <-time.After(wait):
+			delay = min(delay*2, retryMaxDelay)
+		case <-ctx.Done():
+			return canceledError(method, path, ctx.Err())
+		}
+	}
+}
+
+// canceledError returns the error for a request abandoned because its context
+// ended with err. The D1 API has no way to abort a request once it is sent, so
+// only the client stops waiting; the server may still complete it.
+func canceledError(method, path string, err error) error {
+	return fmt.Errorf("%w: %s %s: %w", ErrCanceled, method, path, err)
+}
+
+// countRows adds the rows read and written by the queries whose results were
+// decoded into v to the client's counters. Other results are ignored.
+func (c *Client) countRows(v any) {
+	var metas []QueryMeta
+	switch r := v.(type) {
+	case *QueryResult:
+		metas = append(metas, r.Meta)
+	case *[]QueryResult:
+		for _, qr := range *r {
+			metas = append(metas, qr.Meta)
+		}
+	case *[]RawQueryResult:
+		metas = resultMetas(*r)
+	}
+	if len(metas) == 0 {
+		return
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for _, meta := range metas {
+		c.rowsRead += meta.RowsRead
+		c.rowsWritten += meta.RowsWritten
+	}
+}
+
+// shouldRetry reports whether a request that failed with err on the given
+// attempt should be retried. Requests are only retried if the context is still
+// active, the request is safe to repeat, and the retry predicate allows it.
+func (c *Client) shouldRetry(ctx context.Context, method, path string, reqBytes []byte, err error, attempt int) bool {
+	if c.retryPredicate == nil || ctx.Err() != nil || !isRetryableRequest(method, path, reqBytes, err) {
+		return false
+	}
+	// Query errors are converted to SQLiteError by the caller, which has the
+	// query text; convert here as well so the predicate can inspect them.
+	return c.retryPredicate(convertSQLiteError(err, "", nil), attempt)
+}
+
+// retryAfter returns the delay requested by the Retry-After header in h, given
+// either in seconds or as an HTTP date, and whether there was a valid one.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// isRetryableRequest reports whether a request with the body reqBytes that
+// failed with err may be retried: GET requests, and POST requests that execute
+// queries, if they only read from the database or err shows that they were not
+// applied. A SQLite error shows that a query was not applied only if it is
+// atomic, as the statements of other queries before the failing one may have
+// been committed.
+func isRetryableRequest(method, path string, reqBytes []byte, err error) bool {
+	if method == http.MethodGet {
+		return true
+	}
+	if method != http.MethodPost || !strings.HasSuffix(path, "/query") && !strings.HasSuffix(path, "/raw") {
+		return false
+	}
+	var d1Err *D1Error
+	if errors.As(err, &d1Err) {
+		if d1Err.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		if d1Err.Code == 7500 && d1Err.StatusCode < 500 && isAtomicRequest(reqBytes) {
+			return true
+		}
+	}
+	return isReadOnlyRequest(reqBytes)
+}
+
+// queryRequestBody is the part of the body of a query request, for a single
+// query or a batch, that is inspected to decide whether it may be retried.
+type queryRequestBody struct {
+	SQL   string `json:"sql"`
+	Batch []struct {
+		SQL string `json:"sql"`
+	} `json:"batch"`
+}
+
+// isAtomicRequest reports whether reqBytes is the body of a query request
+// whose statements are committed together or not at all: a batch, which D1
+// runs as a transaction, or a query as classified by isAtomicQuery.
+func isAtomicRequest(reqBytes []byte) bool {
+	var body queryRequestBody
+	if err := json.Unmarshal(reqBytes, &body); err != nil {
+		return false
+	}
+	if body.Batch != nil {
+		return len(body.Batch) > 0
+	}
+	return isAtomicQuery(body.SQL)
+}
+
+// isReadOnlyRequest reports whether reqBytes is the body of a query request,
+// for a single query or a batch, whose statements only read from the database.
+func isReadOnlyRequest(reqBytes []byte) bool {
+	var body queryRequestBody
+	if err := json.Unmarshal(reqBytes, &body); err != nil {
+		return false
+	}
+	if body.Batch == nil {
+		return isReadOnlyQuery(body.SQL)
+	}
+	for _, stmt := range body.Batch {
+		if !isReadOnlyQuery(stmt.SQL) {
+			return false
+		}
+	}
+	return len(body.Batch) > 0
+}
+
+// doRequest makes a single attempt at an HTTP request to the Cloudflare API,
+// with reqBytes as the body, and processes the response.
+func (c *Client) doRequest(ctx context.Context, method, path string, reqBytes []byte, v any, info *responseInfo) error {
+	url := fmt.Sprintf("%s/accounts/%s/d1/%s", c.baseURL, c.accountID, strings.TrimPrefix(path, "/"))
+
+	httpClient := c.httpClient
+	if d, ok := ctx.Value(httpTimeoutKey{}).(time.Duration); ok {
+		// The timeout replaces the HTTP client's, and is applied through the
+		// context so that exceeding it is reported as ErrCanceled.
+		hc := *c.httpClient
+		hc.Timeout = 0
+		httpClient = &hc
+		if d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+	}
+	if c.connStats {
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{GotConn: c.countConn})
+	}
 	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(reqBytes))
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
@@ -289,49 +1014,67 @@ func (c *Client) sendRequest(ctx context.Context, method, path string, body any,
 		return fmt.Errorf("no API token provided")
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return canceledError(method, path, ctxErr)
+		}
 		return fmt.Errorf("sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
+		// The transport aborts reading the body when the context ends, which
+		// would otherwise surface as a read error or a truncated JSON body.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return canceledError(method, path, ctxErr)
+		}
 		return fmt.Errorf("reading response body: %w", err)
 	}
+	if info != nil {
+		info.header = resp.Header
+		if !info.discardBody {
+			info.body = responseBody
+		}
+	}
 
 	if resp.StatusCode >= 500 {
 		// sometimes Cloudflare doesn't return JSON in this case, so wrap this
 		// as a different error
-		return newD1Error(resp.StatusCode, string(responseBody))
+		d1Err := newD1Error(resp.StatusCode, string(responseBody))
+		d1Err.StatusCode = resp.StatusCode
+		return d1Err
 	}
 
 	var apiResp apiResponse
 	if err := json.Unmarshal(responseBody, &apiResp); err != nil {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// Rate limiting may also be reported without a JSON body
+			d1Err := newD1Error(resp.StatusCode, string(responseBody))
+			d1Err.StatusCode = resp.StatusCode
+			return d1Err
+		}
 		return fmt.Errorf("decoding response: %w\n%s", err, string(responseBody))
 	}
 
 	if !apiResp.Success {
 		if len(apiResp.Errors) > 0 {
-			return &apiResp.Errors[0]
+			apiResp.Errors[0].StatusCode = resp.StatusCode
+			return convertPermissionError(&apiResp.Errors[0], method, path, resp.StatusCode)
 		}
 		return fmt.Errorf("API request failed without specific error")
 	}
 
-	if pagInfo != nil {
-		*pagInfo = apiResp.ResultInfo
+	if info != nil {
+		info.resultInfo = apiResp.ResultInfo
 	}
 
 	if v != nil {
 		if err := json.Unmarshal(apiResp.Result, v); err != nil {
 			return fmt.Errorf("decoding JSON result: %w", err)
 		}
-		if qr, ok := v.(*QueryResult); ok { // Update counters for queries
-			c.mux.Lock()
-			defer c.mux.Unlock()
-			c.rowsRead += qr.Meta.RowsRead
-			c.rowsWritten += qr.Meta.RowsWritten
-		}
+		c.countRows(v)
 	}
 
 	return nil
@@ -0,0 +1,626 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a Client whose requests are served by handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc, options ...ClientOption) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewClient("test-account", "test-token", append([]ClientOption{WithEndpoint(srv.URL)}, options...)...)
+}
+
+// writeAPIResult writes result to w wrapped in a successful API response.
+func writeAPIResult(w http.ResponseWriter, result any, info *apiResponseInfo) {
+	resp := map[string]any{
+		"result":  result,
+		"success": true,
+		"errors":  []any{},
+	}
+	if info != nil {
+		resp["result_info"] = info
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeAPIError writes an unsuccessful API response to w.
+func writeAPIError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"result":  nil,
+		"success": false,
+		"errors":  []D1Error{{Code: code, Message: message}},
+	})
+}
+
+func TestListDatabases(t *testing.T) {
+	const total = 250
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		var dbs []DatabaseDetails
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			dbs = append(dbs, DatabaseDetails{Name: fmt.Sprintf("db-%03d", i)})
+		}
+		writeAPIResult(w, dbs, &apiResponseInfo{Page: page, PerPage: perPage, Count: len(dbs), TotalCount: total})
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("Concurrency %d", concurrency), func(t *testing.T) {
+			client := newTestClient(t, handler, WithListConcurrency(concurrency))
+			dbs, err := client.ListDatabases(context.Background(), "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(dbs) != total {
+				t.Fatalf("unexpected count: got %d, want %d", len(dbs), total)
+			}
+			for i, db := range dbs {
+				if want := fmt.Sprintf("db-%03d", i); db.Name != want {
+					t.Fatalf("unexpected order at %d: got %s, want %s", i, db.Name, want)
+				}
+			}
+		})
+	}
+}
+
+func TestListDatabasesConcurrentError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		switch page {
+		case 2:
+			<-r.Context().Done() // canceled when page 3 fails
+			return
+		case 3:
+			writeAPIError(w, http.StatusInternalServerError, 10000, "internal error")
+			return
+		}
+		dbs := make([]DatabaseDetails, perPage)
+		writeAPIResult(w, dbs, &apiResponseInfo{Page: page, PerPage: perPage, Count: len(dbs), TotalCount: 3 * perPage})
+	}, WithListConcurrency(4))
+
+	_, err := client.ListDatabases(context.Background(), "")
+	var d1Err *D1Error
+	if !errors.As(err, &d1Err) || errors.Is(err, ErrCanceled) || !strings.Contains(err.Error(), "page 3") {
+		t.Errorf("expected the page 3 error, got %v", err)
+	}
+}
+
+func TestListDatabasesPaged(t *testing.T) {
+	const total = 25
+	var pages []int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		pages = append(pages, page)
+		if page == 3 {
+			writeAPIError(w, http.StatusInternalServerError, 10000, "internal error")
+			return
+		}
+		var dbs []DatabaseDetails
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			dbs = append(dbs, DatabaseDetails{Name: fmt.Sprintf("db-%03d", i)})
+		}
+		writeAPIResult(w, dbs, &apiResponseInfo{Page: page, PerPage: perPage, Count: len(dbs), TotalCount: total})
+	})
+	ctx := context.Background()
+
+	// Stopping early fetches only the pages needed
+	it := client.ListDatabasesPaged(ctx, "", 10)
+	var names []string
+	for it.Next(ctx) {
+		names = append(names, it.Value().Name)
+		if len(names) == 12 {
+			break
+		}
+	}
+	if it.Err() != nil || names[11] != "db-011" {
+		t.Fatalf("unexpected result: %v, %v", names, it.Err())
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("unexpected pages fetched: got %v, want %v", pages, want)
+	}
+
+	// An error ends the iteration
+	pages = nil
+	it = client.ListDatabasesPaged(ctx, "", 10)
+	var count int
+	for it.Next(ctx) {
+		count++
+	}
+	if count != 20 || it.Err() == nil || !strings.Contains(it.Err().Error(), "page 3") {
+		t.Errorf("got %d databases and error %v; want 20 and a page 3 error", count, it.Err())
+	}
+	if it.Next(ctx) {
+		t.Error("Next returned true after an error")
+	}
+}
+
+func TestNormalizeUUID(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		isUUID   bool
+	}{
+		{"Canonical", "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", true},
+		{"Uppercase", "E4E4E4E4-4555-4777-B222-1A2B3C4D5E6F", "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", true},
+		{"No hyphens", "e4e4e4e445554777b2221a2b3c4d5e6f", "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", true},
+		{"Name", "my-database", "", false},
+		{"Too short", "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := normalizeUUID(tt.input)
+			if ok != tt.isUUID || got != tt.expected {
+				t.Errorf("unexpected result: got %q, %v; want %q, %v", got, ok, tt.expected, tt.isUUID)
+			}
+		})
+	}
+}
+
+func TestFindDatabaseTrimsWhitespace(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected API request: %s %s", r.Method, r.URL)
+	})
+	got, err := client.FindDatabase(context.Background(), " E4E4E4E4-4555-4777-B222-1A2B3C4D5E6F\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f"; got != want {
+		t.Errorf("unexpected result: got %q, want %q", got, want)
+	}
+}
+
+func TestRequestCanceled(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) // lets the server notice the client hanging up
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.Query(ctx, "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", "SELECT 1")
+	if !errors.Is(err, ErrCanceled) {
+		t.Errorf("expected ErrCanceled, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRequestCanceledReadingBody(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"result": [{"results": {"columns": ["x"], "rows": [[1]`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err := client.Query(ctx, "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", "SELECT 1")
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected ErrCanceled wrapping context.Canceled, got %v", err)
+	}
+}
+
+func TestD1ErrorStatusCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		code       int
+		statusCode int
+	}{
+		{"NotFound", func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(w, http.StatusNotFound, 7404, "database not found")
+		}, 7404, http.StatusNotFound},
+		{"BadRequest", func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(w, http.StatusBadRequest, 7400, "bad request")
+		}, 7400, http.StatusBadRequest},
+		{"ServerError", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		}, http.StatusBadGateway, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.handler)
+			_, err := client.GetDatabase(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+			var d1Err *D1Error
+			if !errors.As(err, &d1Err) {
+				t.Fatalf("expected D1Error, got %v", err)
+			}
+			if d1Err.Code != tt.code || d1Err.StatusCode != tt.statusCode {
+				t.Errorf("got code %d, status %d; want code %d, status %d",
+					d1Err.Code, d1Err.StatusCode, tt.code, tt.statusCode)
+			}
+		})
+	}
+}
+
+func TestCreateDatabaseWithOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CreateDatabaseOptions
+		want map[string]string
+	}{
+		{"Defaults", CreateDatabaseOptions{}, map[string]string{"name": "db"}},
+		{"Location hint", CreateDatabaseOptions{PrimaryLocationHint: LocationHintWesternEurope},
+			map[string]string{"name": "db", "primary_location_hint": "weur"}},
+		{"Jurisdiction", CreateDatabaseOptions{Jurisdiction: JurisdictionEU},
+			map[string]string{"name": "db", "jurisdiction": "eu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]string
+				json.NewDecoder(r.Body).Decode(&body)
+				if !reflect.DeepEqual(body, tt.want) {
+					t.Errorf("got body %v, want %v", body, tt.want)
+				}
+				writeAPIResult(w, DatabaseDetails{Name: body["name"], Jurisdiction: Jurisdiction(body["jurisdiction"])}, nil)
+			})
+
+			details, err := client.CreateDatabaseWithOptions(context.Background(), "db", tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if details.Jurisdiction != tt.opts.Jurisdiction {
+				t.Errorf("got jurisdiction %q, want %q", details.Jurisdiction, tt.opts.Jurisdiction)
+			}
+		})
+	}
+}
+
+func TestGetDatabaseHeaders(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v42"`)
+		w.Header().Set("Last-Modified", "Tue, 15 Sep 2026 10:00:00 GMT")
+		writeAPIResult(w, DatabaseDetails{Name: "db"}, nil)
+	})
+
+	details, err := client.GetDatabase(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if details.ETag != `"v42"` {
+		t.Errorf("unexpected ETag: %q", details.ETag)
+	}
+	if want := time.Date(2026, 9, 15, 10, 0, 0, 0, time.UTC); !details.LastModified.Equal(want) {
+		t.Errorf("unexpected LastModified: got %v, want %v", details.LastModified, want)
+	}
+}
+
+func TestDetailsCache(t *testing.T) {
+	const dbID = "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f"
+	var detailsRequests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/raw") {
+			var req rawQueryRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			var rs RawQueryResult
+			rs.Meta.ChangedDB = strings.HasPrefix(req.SQL, "CREATE")
+			writeAPIResult(w, []RawQueryResult{rs}, nil)
+			return
+		}
+		detailsRequests++
+		writeAPIResult(w, DatabaseDetails{UUID: dbID, NumTables: detailsRequests}, nil)
+	}, WithDetailsCache(time.Minute))
+	ctx := context.Background()
+	h, _ := client.GetHandle(ctx, dbID)
+
+	steps := []struct {
+		name      string
+		action    func() error
+		ctx       context.Context
+		numTables int
+	}{
+		{"Initial", nil, ctx, 1},
+		{"Cached", nil, ctx, 1},
+		{"Force refresh", nil, ForceRefresh(ctx), 2},
+		{"Read does not invalidate", func() error { _, err := h.rawQuery(ctx, "SELECT 1"); return err }, ctx, 2},
+		{"Write invalidates", func() error { _, err := h.rawQuery(ctx, "CREATE TABLE t (a)"); return err }, ctx, 3},
+	}
+	for _, step := range steps {
+		if step.action != nil {
+			if err := step.action(); err != nil {
+				t.Fatalf("%s: unexpected error: %v", step.name, err)
+			}
+		}
+		details, err := h.GetDetails(step.ctx)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		if details.NumTables != step.numTables {
+			t.Errorf("%s: NumTables = %d, want %d", step.name, details.NumTables, step.numTables)
+		}
+	}
+}
+
+func TestClientPing(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		dbID    string
+		target  error
+		wantErr bool
+	}{
+		{"Not a UUID", func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}, "my-database", nil, true},
+		{"Reachable without hyphens", func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.URL.Path, "/e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f/raw") {
+				t.Errorf("unexpected path: %s", r.URL.Path)
+			}
+			writeAPIResult(w, []RawQueryResult{rawResult([]string{"1"}, []any{1.0})}, nil)
+		}, "E4E4E4E445554777B2221A2B3C4D5E6F", nil, false},
+		{"Not found", func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(w, http.StatusNotFound, 7404, "The database e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f could not be found")
+		}, "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", ErrNotFound, true},
+		{"Unauthorized", func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(w, http.StatusForbidden, 10000, "Authentication error")
+		}, "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", ErrPermissionDenied, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.handler)
+			err := client.Ping(context.Background(), tt.dbID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Ping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.target != nil && !errors.Is(err, tt.target) {
+				t.Errorf("Ping() error = %v, want %v", err, tt.target)
+			}
+			if errors.Is(err, ErrNotFound) != (tt.target == ErrNotFound) {
+				t.Errorf("Ping() error = %v, unexpected ErrNotFound match", err)
+			}
+		})
+	}
+}
+
+func TestClientDo(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/accounts/test-account/d1/database/e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f/new_feature" {
+			writeAPIError(w, http.StatusNotFound, 7404, "not found")
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("missing authorization header")
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		writeAPIResult(w, map[string]string{"echo": body["value"]}, nil)
+	})
+
+	var out struct {
+		Echo string `json:"echo"`
+	}
+	err := client.Do(context.Background(), http.MethodPost, "database/e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f/new_feature",
+		map[string]string{"value": "hello"}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Echo != "hello" {
+		t.Errorf("unexpected result: %q", out.Echo)
+	}
+
+	err = client.Do(context.Background(), http.MethodGet, "/database/missing", nil, nil)
+	var d1Err *D1Error
+	if !errors.As(err, &d1Err) || d1Err.Code != 7404 {
+		t.Errorf("expected D1Error 7404, got %v", err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		statuses []int // statuses of the failed responses, before success
+		requests int
+		wantErr  int // status code of the expected error, or 0
+	}{
+		{"Server errors", "SELECT 1", []int{503, 500}, 3, 0},
+		{"Rate limited", "SELECT 1", []int{429}, 2, 0},
+		{"Exhausted", "SELECT 1", []int{429, 429, 429, 429}, 3, 429},
+		{"Client error", "SELECT 1", []int{400}, 1, 400},
+		{"Write after server error", "INSERT INTO t VALUES (1)", []int{503}, 1, 503},
+		{"Write rate limited", "INSERT INTO t VALUES (1)", []int{429}, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= len(tt.statuses) {
+					status := tt.statuses[requests-1]
+					if status == http.StatusTooManyRequests {
+						w.Header().Set("Retry-After", "0")
+						w.WriteHeader(status)
+						io.WriteString(w, "rate limited")
+						return
+					}
+					writeAPIError(w, status, status, "failed")
+					return
+				}
+				writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1})}, nil)
+			}, WithRetry(3, time.Millisecond))
+
+			_, err := client.RawQuery(context.Background(), "db", tt.sql)
+			var d1Err *D1Error
+			if tt.wantErr == 0 && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if tt.wantErr != 0 && (!errors.As(err, &d1Err) || d1Err.StatusCode != tt.wantErr) {
+				t.Errorf("expected D1Error with status %d, got %v", tt.wantErr, err)
+			}
+			if requests != tt.requests {
+				t.Errorf("made %d requests, want %d", requests, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"Missing", "", 0, false},
+		{"Seconds", "3", 3 * time.Second, true},
+		{"Past date", "Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+		{"Invalid", "soon", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.header != "" {
+				h.Set("Retry-After", tt.header)
+			}
+			got, ok := retryAfter(h)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("retryAfter(%q) = (%v, %v), want (%v, %v)", tt.header, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRetryPredicate(t *testing.T) {
+	var attempts []int
+	predicate := WithRetryPredicate(func(err error, attempt int) bool {
+		attempts = append(attempts, attempt)
+		var sqliteErr *SQLiteError
+		return attempt < 5 && errors.As(err, &sqliteErr) && sqliteErr.SQLiteCode == "SQLITE_BUSY"
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		attempts = nil
+		var requests int
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests < 3 {
+				writeAPIError(w, http.StatusBadRequest, 7500, "database is locked: SQLITE_BUSY")
+				return
+			}
+			writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1.0})}, nil)
+		}, predicate)
+
+		if _, err := client.RawQuery(context.Background(), "db", "SELECT 1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := []int{1, 2}; fmt.Sprint(attempts) != fmt.Sprint(want) {
+			t.Errorf("unexpected predicate calls: got %v, want %v", attempts, want)
+		}
+	})
+
+	t.Run("Write rejected by SQLite", func(t *testing.T) {
+		attempts = nil
+		var requests int
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests < 2 {
+				writeAPIError(w, http.StatusBadRequest, 7500, "database is locked: SQLITE_BUSY")
+				return
+			}
+			writeAPIResult(w, []RawQueryResult{rawResult(nil)}, nil)
+		}, predicate)
+
+		if _, err := client.RawQuery(context.Background(), "db", "UPDATE t SET x = 1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if requests != 2 {
+			t.Errorf("made %d requests, want 2", requests)
+		}
+	})
+
+	t.Run("Multi-statement write failing partway", func(t *testing.T) {
+		attempts = nil
+		var requests int
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			writeAPIError(w, http.StatusBadRequest, 7500, "database is locked: SQLITE_BUSY")
+		}, predicate)
+
+		// The first UPDATE may have been committed before the second failed.
+		_, err := client.RawQuery(context.Background(), "db", "UPDATE t SET x = x + 1; UPDATE u SET y = 1")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if requests != 1 || len(attempts) != 0 {
+			t.Errorf("made %d requests with predicate calls %v, want 1 and none", requests, attempts)
+		}
+	})
+
+	t.Run("Multi-statement write in transaction", func(t *testing.T) {
+		for _, tt := range []struct {
+			sql  string
+			opts []ClientOption
+		}{
+			{"BEGIN; UPDATE t SET x = x + 1; UPDATE u SET y = 1; COMMIT", []ClientOption{predicate}},
+			{"UPDATE t SET x = x + 1; UPDATE u SET y = 1", []ClientOption{predicate, WithImplicitTransactions()}},
+		} {
+			var requests int
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests < 2 {
+					writeAPIError(w, http.StatusBadRequest, 7500, "database is locked: SQLITE_BUSY")
+					return
+				}
+				writeAPIResult(w, []RawQueryResult{rawResult(nil), rawResult(nil), rawResult(nil), rawResult(nil)}, nil)
+			}, tt.opts...)
+
+			if _, err := client.RawQuery(context.Background(), "db", tt.sql); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if requests != 2 {
+				t.Errorf("made %d requests, want 2", requests)
+			}
+		}
+	})
+
+	t.Run("Not retryable", func(t *testing.T) {
+		attempts = nil
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(w, http.StatusBadRequest, 7500, "database is locked: SQLITE_BUSY")
+		}, predicate)
+
+		if _, err := client.CreateDatabase(context.Background(), "db", ""); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if len(attempts) != 0 {
+			t.Errorf("predicate called for non-retryable request: %v", attempts)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(w, http.StatusBadRequest, 7500, "database is locked: SQLITE_BUSY")
+		}, WithRetryPredicate(func(err error, attempt int) bool { return true }))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err := client.RawQuery(ctx, "db", "SELECT 1")
+		if !errors.Is(err, ErrCanceled) {
+			t.Errorf("expected ErrCanceled, got %v", err)
+		}
+	})
+}
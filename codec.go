@@ -0,0 +1,73 @@
+package cfd1
+
+import (
+	"reflect"
+	"sync"
+)
+
+// typeCodec holds the functions registered for a type with RegisterType.
+type typeCodec struct {
+	encode func(any) (any, error)
+	decode func(any, *reflect.Value) error
+}
+
+var (
+	typeCodecsMu sync.RWMutex
+	typeCodecs   = make(map[reflect.Type]typeCodec)
+)
+
+// RegisterType registers functions that convert values of type t to and from
+// the values stored in the database, so that t can be used as a query
+// parameter and as a scan destination without converting it at every call
+// site. For example, a UUID type can be registered to be stored as TEXT:
+//
+//	cfd1.RegisterType(reflect.TypeOf(uuid.UUID{}),
+//	    func(v any) (any, error) { return v.(uuid.UUID).String(), nil },
+//	    func(src any, dest *reflect.Value) error {
+//	        s, ok := src.(string)
+//	        if !ok {
+//	            return fmt.Errorf("cannot scan %T into uuid.UUID", src)
+//	        }
+//	        id, err := uuid.Parse(s)
+//	        if err != nil {
+//	            return err
+//	        }
+//	        dest.Set(reflect.ValueOf(id))
+//	        return nil
+//	    })
+//
+// When a query parameter has type t, encode is called with it, and the value
+// it returns is sent in its place, subject to the usual conversions of
+// parameters, such as time.Time to a Unix timestamp. When a value is scanned
+// into a destination of type t, decode is called with the value from the
+// database and the settable destination. NULL values are scanned as the zero
+// value of t without calling decode. Either function may be nil to register
+// conversion in one direction only.
+//
+// Registered functions take precedence over the methods of t: encode is used
+// instead of a driver.Valuer Value method, and decode instead of an sql.Scanner
+// Scan method. Only the exact type t is matched; register pointer types
+// separately if they are used. Registering t again replaces its functions.
+// Registration applies to all clients, and to the database/sql driver.
+func RegisterType(t reflect.Type, encode func(any) (any, error), decode func(any, *reflect.Value) error) {
+	typeCodecsMu.Lock()
+	defer typeCodecsMu.Unlock()
+	typeCodecs[t] = typeCodec{encode: encode, decode: decode}
+}
+
+// typeEncoder returns the encode function registered for the type of v, if any.
+func typeEncoder(v any) func(any) (any, error) {
+	if v == nil {
+		return nil
+	}
+	typeCodecsMu.RLock()
+	defer typeCodecsMu.RUnlock()
+	return typeCodecs[reflect.TypeOf(v)].encode
+}
+
+// typeDecoder returns the decode function registered for t, if any.
+func typeDecoder(t reflect.Type) func(any, *reflect.Value) error {
+	typeCodecsMu.RLock()
+	defer typeCodecsMu.RUnlock()
+	return typeCodecs[t].decode
+}
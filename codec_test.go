@@ -0,0 +1,97 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// testCodecID is a type registered with RegisterType by the tests. It also
+// implements sql.Scanner, to check that the registered decoder takes
+// precedence.
+type testCodecID struct {
+	hi, lo uint32
+}
+
+func (id *testCodecID) Scan(src any) error {
+	return fmt.Errorf("Scan called instead of registered decoder")
+}
+
+func init() {
+	RegisterType(reflect.TypeOf(testCodecID{}),
+		func(v any) (any, error) {
+			id := v.(testCodecID)
+			return fmt.Sprintf("%08x-%08x", id.hi, id.lo), nil
+		},
+		func(src any, dest *reflect.Value) error {
+			s, ok := src.(string)
+			if !ok {
+				return fmt.Errorf("cannot decode %T into testCodecID", src)
+			}
+			var id testCodecID
+			if _, err := fmt.Sscanf(s, "%08x-%08x", &id.hi, &id.lo); err != nil {
+				return err
+			}
+			dest.Set(reflect.ValueOf(id))
+			return nil
+		})
+}
+
+func TestRegisterType(t *testing.T) {
+	id := testCodecID{hi: 0xdeadbeef, lo: 42}
+	const text = "deadbeef-0000002a"
+
+	params, err := convertTypes([]any{id, 1})
+	if err != nil {
+		t.Fatalf("convertTypes() error = %v", err)
+	}
+	if !reflect.DeepEqual(params, []any{text, 1}) {
+		t.Errorf("convertTypes() = %v", params)
+	}
+
+	tests := []struct {
+		name     string
+		src      any
+		expected testCodecID
+		wantErr  bool
+	}{
+		{"Text", text, id, false},
+		{"NULL", nil, testCodecID{}, false},
+		{"Wrong type", 1.0, testCodecID{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testCodecID
+			err := assign(&got, tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("assign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("assign() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegisterTypeDriver(t *testing.T) {
+	id := testCodecID{hi: 1, lo: 2}
+	db := openTestDB(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !reflect.DeepEqual(req.Params, []any{"00000001-00000002"}) {
+			t.Errorf("unexpected params: %v", req.Params)
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"id"}, []any{req.Params[0]})}, nil)
+	})
+
+	var got string
+	if err := db.QueryRowContext(context.Background(), "SELECT ?", id).Scan(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "00000001-00000002" {
+		t.Errorf("unexpected result: %q", got)
+	}
+}
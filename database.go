@@ -2,13 +2,22 @@ package cfd1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 )
 
+// listPageSize is the number of databases requested per page when listing.
+const listPageSize = 100
+
+// MaxDatabaseSize is the maximum size of a D1 database in bytes (10 GB). Writes
+// that would grow a database beyond this size fail with [ErrDatabaseFull].
+const MaxDatabaseSize = 10_000_000_000
+
 // LocationHint represents the geographical location hint for database creation.
 type LocationHint string
 
@@ -24,7 +33,36 @@ const (
 	LocationHintOceania                          = "oc"
 )
 
-// DatabaseDetails represents information about a D1 database.
+// Jurisdiction restricts the data centers in which a database's data is stored
+// and processed, for data residency requirements.
+type Jurisdiction string
+
+// Jurisdiction constants specify where a database's data may reside.
+// JurisdictionNone places no restriction beyond the [LocationHint].
+const (
+	JurisdictionNone    Jurisdiction = ""
+	JurisdictionEU      Jurisdiction = "eu"      // European Union
+	JurisdictionFedRAMP Jurisdiction = "fedramp" // FedRAMP-compliant data centers
+)
+
+// CreateDatabaseOptions holds the settings for
+// [Client.CreateDatabaseWithOptions].
+//
+// The D1 API does not provide a way to list the regions in which read replicas
+// may be placed; setting a Jurisdiction constrains the primary and any
+// replicas alike.
+type CreateDatabaseOptions struct {
+	// PrimaryLocationHint is the preferred location of the database's primary.
+	PrimaryLocationHint LocationHint
+
+	// Jurisdiction, if set, restricts where the database's data is stored and
+	// processed. It cannot be changed after the database is created.
+	Jurisdiction Jurisdiction
+}
+
+// DatabaseDetails represents information about a D1 database. Version
+// identifies the version of D1's storage backend, such as "production"; the
+// version of the SQLite engine is reported by [Handle.SQLiteVersion].
 type DatabaseDetails struct {
 	CreatedAt time.Time `json:"created_at"`
 	Name      string    `json:"name"`
@@ -32,13 +70,28 @@ type DatabaseDetails struct {
 	Version   string    `json:"version"`
 	FileSize  int       `json:"file_size"`
 	NumTables int       `json:"num_tables"`
+
+	// Jurisdiction is the jurisdiction the database was created in, or empty if
+	// it has none, or if the API did not report it.
+	Jurisdiction Jurisdiction `json:"jurisdiction,omitempty"`
+
+	// ETag and LastModified hold the ETag and Last-Modified headers of the
+	// response that returned these details, if any, for use in optimistic
+	// concurrency checks. They are only set by [Client.GetDatabase]. The D1 API
+	// does not currently send these headers, nor honor conditional requests
+	// using If-Match, so callers should fall back to comparing Version or other
+	// fields when they are empty.
+	ETag         string    `json:"-"`
+	LastModified time.Time `json:"-"`
 }
 
 // ListDatabases returns all databases associated with the account. If name is
 // non-empty, it filters results to databases including that name ('LIKE
 // %name%'). Returns a slice of [DatabaseDetails]. Note that although the
 // underlying D1 API supports pagination, this method automatically fetches all
-// pages of results.
+// pages of results; use [Client.ListDatabasesPaged] to fetch them one at a time.
+// If the client was created with [WithListConcurrency], pages after the first
+// are fetched concurrently; the returned slice is in the same order either way.
 //
 // Example usage:
 //
@@ -50,25 +103,101 @@ type DatabaseDetails struct {
 //	    fmt.Printf("Database: %s (UUID: %s)\n", db.Name, db.UUID)
 //	}
 func (c *Client) ListDatabases(ctx context.Context, name string) ([]DatabaseDetails, error) {
-	var allDatabases []DatabaseDetails
-	page := 1
-	perPage := 100
-
-	for {
-		databases, hasMore, err := c.listDatabasesPage(ctx, page, perPage, name)
+	it := c.ListDatabasesPaged(ctx, name, listPageSize)
+	if c.listConcurrency > 1 && it.err == nil && it.info.hasMore() && it.info.PerPage > 0 {
+		rest, err := c.listDatabasesConcurrent(ctx, name, it.info)
 		if err != nil {
-			return nil, fmt.Errorf("listing databases (page %d): %w", page, err)
+			return nil, err
 		}
+		return append(it.buf, rest...), nil
+	}
 
-		allDatabases = append(allDatabases, databases...)
-		if !hasMore {
-			break
-		}
+	var allDatabases []DatabaseDetails
+	for it.Next(ctx) {
+		allDatabases = append(allDatabases, it.Value())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return allDatabases, nil
+}
+
+// DatabaseIterator iterates over the databases listed by
+// [Client.ListDatabasesPaged], fetching one page at a time as needed. Call Next
+// to advance to each database, and Value to get it. When Next returns false,
+// Err reports any error that ended the iteration. A DatabaseIterator is not
+// safe for concurrent use.
+type DatabaseIterator struct {
+	client  *Client
+	name    string
+	perPage int
+	page    int             // the last page fetched
+	info    apiResponseInfo // of the last page fetched
+	buf     []DatabaseDetails
+	value   DatabaseDetails
+	err     error
+}
 
-		page++
+// ListDatabasesPaged returns an iterator over the databases associated with the
+// account, filtered by name as for [Client.ListDatabases]. Unlike
+// ListDatabases, it requests perPage databases at a time, or 100 if perPage is
+// not positive, and only requests the next page once the databases of the
+// previous one have been consumed, so iteration can stop early without fetching
+// the rest. The first page is requested immediately, using ctx; later pages are
+// requested by [DatabaseIterator.Next], using the context passed to it.
+//
+// Example usage:
+//
+//	it := client.ListDatabasesPaged(ctx, "", 50)
+//	for it.Next(ctx) {
+//	    db := it.Value()
+//	    if db.Name == "orders" {
+//	        break
+//	    }
+//	}
+//	if err := it.Err(); err != nil {
+//	    // handle error
+//	}
+func (c *Client) ListDatabasesPaged(ctx context.Context, name string, perPage int) *DatabaseIterator {
+	if perPage <= 0 {
+		perPage = listPageSize
 	}
+	it := &DatabaseIterator{client: c, name: name, perPage: perPage}
+	it.fetch(ctx)
+	return it
+}
 
-	return allDatabases, nil
+// Next advances the iterator to the next database, fetching the next page of
+// results if needed, and reports whether there was one.
+func (it *DatabaseIterator) Next(ctx context.Context) bool {
+	for len(it.buf) == 0 {
+		if it.err != nil || (it.page > 0 && !it.info.hasMore()) {
+			return false
+		}
+		it.fetch(ctx)
+	}
+	it.value, it.buf = it.buf[0], it.buf[1:]
+	return true
+}
+
+// Value returns the database that the iterator is positioned at, after a call
+// to Next that returned true.
+func (it *DatabaseIterator) Value() DatabaseDetails {
+	return it.value
+}
+
+// Err returns the error, if any, that ended the iteration.
+func (it *DatabaseIterator) Err() error {
+	return it.err
+}
+
+// fetch requests the next page of databases into the iterator's buffer.
+func (it *DatabaseIterator) fetch(ctx context.Context) {
+	it.page++
+	it.buf, it.info, it.err = it.client.listDatabasesPage(ctx, it.page, it.perPage, it.name)
+	if it.err != nil {
+		it.err = fmt.Errorf("listing databases (page %d): %w", it.page, it.err)
+	}
 }
 
 // CreateDatabase creates a new database with the given name and [LocationHint].
@@ -83,9 +212,25 @@ func (c *Client) ListDatabases(ctx context.Context, name string) ([]DatabaseDeta
 //	}
 //	fmt.Printf("Created database: %s (UUID: %s)\n", dbDetails.Name, dbDetails.UUID)
 func (c *Client) CreateDatabase(ctx context.Context, name string, primaryLocationHint LocationHint) (*DatabaseDetails, error) {
+	return c.CreateDatabaseWithOptions(ctx, name, CreateDatabaseOptions{PrimaryLocationHint: primaryLocationHint})
+}
+
+// CreateDatabaseWithOptions creates a new database with the given name and
+// options, such as a [Jurisdiction] to meet data residency requirements.
+// Otherwise, it is the same as [Client.CreateDatabase].
+//
+// Example usage:
+//
+//	dbDetails, err := client.CreateDatabaseWithOptions(ctx, "eu-customers", cfd1.CreateDatabaseOptions{
+//	    Jurisdiction: cfd1.JurisdictionEU,
+//	})
+func (c *Client) CreateDatabaseWithOptions(ctx context.Context, name string, opts CreateDatabaseOptions) (*DatabaseDetails, error) {
 	body := map[string]string{"name": name}
-	if primaryLocationHint != "" {
-		body["primary_location_hint"] = string(primaryLocationHint)
+	if opts.PrimaryLocationHint != "" {
+		body["primary_location_hint"] = string(opts.PrimaryLocationHint)
+	}
+	if opts.Jurisdiction != "" {
+		body["jurisdiction"] = string(opts.Jurisdiction)
 	}
 	var result DatabaseDetails
 	err := c.sendRequest(ctx, http.MethodPost, "/database", body, &result, nil)
@@ -98,25 +243,126 @@ func (c *Client) CreateDatabase(ctx context.Context, name string, primaryLocatio
 // GetDatabase retrieves details about the database identified by databaseID.
 // Returns a [DatabaseDetails] struct.
 func (c *Client) GetDatabase(ctx context.Context, databaseID string) (*DatabaseDetails, error) {
+	if cached, ok := c.detailsCache.get(ctx, databaseID); ok {
+		return cached, nil
+	}
+
 	var result DatabaseDetails
-	err := c.sendRequest(ctx, http.MethodGet, fmt.Sprintf("/database/%s", databaseID), nil, &result, nil)
+	var info responseInfo
+	err := c.sendRequest(ctx, http.MethodGet, fmt.Sprintf("/database/%s", databaseID), nil, &result, &info)
 	if err != nil {
 		return nil, fmt.Errorf("getting database details: %w", err)
 	}
+	result.ETag = info.header.Get("ETag")
+	if lastModified := info.header.Get("Last-Modified"); lastModified != "" {
+		result.LastModified, _ = http.ParseTime(lastModified)
+	}
+	c.detailsCache.put(databaseID, result)
 	return &result, nil
 }
 
+// WithDetailsCache enables caching of the [DatabaseDetails] returned by
+// [Client.GetDatabase] and [Handle.GetDetails] for up to ttl, which reduces API
+// requests when polling many databases for metadata that changes slowly, such
+// as NumTables and Version. The cache is keyed by database UUID and shared by
+// all handles of the client. A database's entry is discarded when a query
+// through the client changes the database, and when the database is imported
+// into, restored, or deleted. Other changes, such as those made by other
+// clients, are not seen until the entry expires. To bypass the cache for a
+// single call, use [ForceRefresh].
+func WithDetailsCache(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.detailsCache = &detailsCache{ttl: ttl, entries: make(map[string]detailsCacheEntry)}
+	}
+}
+
+// forceRefreshKey is the context key set by ForceRefresh.
+type forceRefreshKey struct{}
+
+// ForceRefresh returns a copy of ctx that makes [Client.GetDatabase] and
+// [Handle.GetDetails] retrieve database details from the API even when they are
+// cached by [WithDetailsCache]. The cache is updated with the result.
+//
+// Example usage:
+//
+//	details, err := h.GetDetails(cfd1.ForceRefresh(ctx))
+func ForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+// detailsCache caches database details for a client. A nil *detailsCache
+// caches nothing.
+type detailsCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]detailsCacheEntry
+}
+
+type detailsCacheEntry struct {
+	details DatabaseDetails
+	expires time.Time
+}
+
+// get returns a copy of the cached details of databaseID, if they have not
+// expired and ctx does not force a refresh.
+func (dc *detailsCache) get(ctx context.Context, databaseID string) (*DatabaseDetails, bool) {
+	if dc == nil || ctx.Value(forceRefreshKey{}) != nil {
+		return nil, false
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	entry, ok := dc.entries[databaseID]
+	if !ok || !time.Now().Before(entry.expires) {
+		return nil, false
+	}
+	details := entry.details
+	return &details, true
+}
+
+// put caches details for databaseID.
+func (dc *detailsCache) put(databaseID string, details DatabaseDetails) {
+	if dc == nil {
+		return
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.entries[databaseID] = detailsCacheEntry{details: details, expires: time.Now().Add(dc.ttl)}
+}
+
+// invalidate discards the cached details of databaseID, if any.
+func (dc *detailsCache) invalidate(databaseID string) {
+	if dc == nil {
+		return
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	delete(dc.entries, databaseID)
+}
+
 // DeleteDatabase permanently deletes the database identified by databaseID.
 func (c *Client) DeleteDatabase(ctx context.Context, databaseID string) error {
 	err := c.sendRequest(ctx, http.MethodDelete, fmt.Sprintf("/database/%s", databaseID), nil, nil, nil)
+	c.detailsCache.invalidate(databaseID)
 	if err != nil {
 		return fmt.Errorf("deleting database: %w", err)
 	}
 	return nil
 }
 
+// RenameDatabase renames the database identified by databaseID to newName, and
+// returns its updated [DatabaseDetails].
+//
+// The D1 API does not currently support renaming a database; a database's name
+// is fixed when it is created. Until it does, this method returns an error
+// wrapping [ErrNotSupported] without contacting the API. To move data to a
+// database with a different name, create the new database and copy the data
+// using [Client.Export] and [Client.Import].
+func (c *Client) RenameDatabase(ctx context.Context, databaseID, newName string) (*DatabaseDetails, error) {
+	return nil, fmt.Errorf("renaming database %s to %q: %w", databaseID, newName, ErrNotSupported)
+}
+
 // listDatabasesPage retrieves a single page of databases.
-func (c *Client) listDatabasesPage(ctx context.Context, page, perPage int, name string) ([]DatabaseDetails, bool, error) {
+func (c *Client) listDatabasesPage(ctx context.Context, page, perPage int, name string) ([]DatabaseDetails, apiResponseInfo, error) {
 	queryParams := url.Values{}
 	queryParams.Set("page", strconv.Itoa(page))
 	queryParams.Set("per_page", strconv.Itoa(perPage))
@@ -126,13 +372,72 @@ func (c *Client) listDatabasesPage(ctx context.Context, page, perPage int, name
 
 	path := fmt.Sprintf("/database?%s", queryParams.Encode())
 
-	var pageInfo apiResponseInfo
+	var info responseInfo
 	var pageData []DatabaseDetails
-	err := c.sendRequest(ctx, http.MethodGet, path, nil, &pageData, &pageInfo)
+	err := c.sendRequest(ctx, http.MethodGet, path, nil, &pageData, &info)
 	if err != nil {
-		return nil, false, err
+		return nil, info.resultInfo, err
+	}
+
+	return pageData, info.resultInfo, nil
+}
+
+// listDatabasesConcurrent fetches the pages following the first page, described
+// by first, using up to c.listConcurrency concurrent requests. The pages are
+// returned concatenated in page order.
+func (c *Client) listDatabasesConcurrent(ctx context.Context, name string, first apiResponseInfo) ([]DatabaseDetails, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	numPages := (first.TotalCount + first.PerPage - 1) / first.PerPage
+	pages := make([][]DatabaseDetails, numPages+1)
+	sem := make(chan struct{}, c.listConcurrency)
+	var wg sync.WaitGroup
+
+	var mu sync.Mutex
+	var firstErr error
+	var errPage int
+	fail := func(page int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// The pages aborted by cancel fail with cancellation errors, which
+		// must not hide the error that caused it.
+		if firstErr == nil || isCancellation(firstErr) && !isCancellation(err) {
+			firstErr, errPage = err, page
+		}
+		cancel() // no point fetching the remaining pages
 	}
 
-	hasMore := pageInfo.Count > 0 && pageInfo.Page*pageInfo.PerPage < pageInfo.TotalCount
-	return pageData, hasMore, nil
+	for page := 2; page <= numPages; page++ {
+		wg.Add(1)
+		go func(page int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				fail(page, ctx.Err())
+				return
+			}
+			var err error
+			if pages[page], _, err = c.listDatabasesPage(ctx, page, first.PerPage, name); err != nil {
+				fail(page, err)
+			}
+		}(page)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, fmt.Errorf("listing databases (page %d): %w", errPage, firstErr)
+	}
+	var result []DatabaseDetails
+	for page := 2; page <= numPages; page++ {
+		result = append(result, pages[page]...)
+	}
+	return result, nil
+}
+
+// isCancellation reports whether err results from a canceled context.
+func isCancellation(err error) bool {
+	return errors.Is(err, ErrCanceled) || errors.Is(err, context.Canceled)
 }
@@ -2,6 +2,7 @@ package cfd1
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"net/http"
 )
@@ -13,24 +14,91 @@ type DebugLogger interface {
 	LogRequest(method string, url string, requestBody, responseBody []byte, statusCode int)
 }
 
+// DebugRedactor is a function that rewrites a request or response body before
+// it is passed to a [DebugLogger], for example to remove sensitive values. It
+// must not modify body in place; it returns the body to be logged.
+type DebugRedactor func(body []byte) []byte
+
+// RedactQueryParams is a [DebugRedactor] that replaces every bound parameter
+// value in a query request body with the placeholder string "?", so that the
+// SQL text remains visible in logs while parameter values do not. Bodies that
+// are not JSON are returned unchanged.
+func RedactQueryParams(body []byte) []byte {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	redacted, err := json.Marshal(redactParams(v))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// RedactParams returns a copy of params with every value replaced by the
+// placeholder string "?". It can be used to omit parameter values from logs,
+// for example in a [SlowQueryHandler].
+func RedactParams(params []any) []any {
+	redacted := make([]any, len(params))
+	for i := range redacted {
+		redacted[i] = "?"
+	}
+	return redacted
+}
+
+// redactParams walks a decoded JSON value and replaces the elements of any
+// "params" array with placeholders.
+func redactParams(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, elem := range val {
+			if params, ok := elem.([]any); ok && k == "params" {
+				val[k] = RedactParams(params)
+				continue
+			}
+			val[k] = redactParams(elem)
+		}
+	case []any:
+		for i, elem := range val {
+			val[i] = redactParams(elem)
+		}
+	}
+	return v
+}
+
 // debugTransport is an http.RoundTripper that captures request and response data
 type debugTransport struct {
 	transport http.RoundTripper
 	logger    DebugLogger
+	redact    DebugRedactor
 }
 
 // RoundTrip executes an HTTP request and captures request and response data.
 func (d *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	reqBody, _ := io.ReadAll(req.Body)
-	req.Body = io.NopCloser(bytes.NewBuffer(reqBody))
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewBuffer(reqBody))
+	}
 
 	resp, err := d.transport.RoundTrip(req)
 	if err != nil {
 		return nil, err
 	}
-	respBody, _ := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
 	resp.Body = io.NopCloser(bytes.NewBuffer(respBody))
 
-	d.logger.LogRequest(req.Method, req.URL.String(), reqBody, respBody, resp.StatusCode)
+	logReqBody, logRespBody := reqBody, respBody
+	if d.redact != nil {
+		logReqBody, logRespBody = d.redact(reqBody), d.redact(respBody)
+	}
+	d.logger.LogRequest(req.Method, req.URL.String(), logReqBody, logRespBody, resp.StatusCode)
 	return resp, nil
 }
@@ -0,0 +1,70 @@
+package cfd1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// debugLoggerFunc is a DebugLogger that calls itself for each request.
+type debugLoggerFunc func(method, url string, requestBody, responseBody []byte, statusCode int)
+
+func (f debugLoggerFunc) LogRequest(method, url string, requestBody, responseBody []byte, statusCode int) {
+	f(method, url, requestBody, responseBody, statusCode)
+}
+
+func TestDebugTransportReadError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail partway through the response body
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("partial"))
+		panic(http.ErrAbortHandler)
+	}))
+	defer srv.Close()
+
+	var logged int
+	transport := &debugTransport{
+		transport: http.DefaultTransport,
+		logger:    debugLoggerFunc(func(string, string, []byte, []byte, int) { logged++ }),
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if resp, err := transport.RoundTrip(req); err == nil {
+		resp.Body.Close()
+		t.Error("expected an error for a truncated response body")
+	}
+	if logged != 0 {
+		t.Errorf("logged %d requests, want 0", logged)
+	}
+}
+
+func TestRedactQueryParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"Single query", `{"sql":"SELECT ?","params":["secret",42]}`, `{"params":["?","?"],"sql":"SELECT ?"}`},
+		{"No params", `{"sql":"SELECT 1"}`, `{"sql":"SELECT 1"}`},
+		{"Batch", `{"batch":[{"sql":"SELECT ?","params":["a"]},{"sql":"SELECT ?","params":["b"]}]}`,
+			`{"batch":[{"params":["?"],"sql":"SELECT ?"},{"params":["?"],"sql":"SELECT ?"}]}`},
+		{"Not JSON", `not json`, `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RedactQueryParams([]byte(tt.body))
+			var gotVal, wantVal any
+			if json.Unmarshal(got, &gotVal) != nil || json.Unmarshal([]byte(tt.expected), &wantVal) != nil {
+				if string(got) != tt.expected {
+					t.Errorf("unexpected result: got %s, want %s", got, tt.expected)
+				}
+				return
+			}
+			if !reflect.DeepEqual(gotVal, wantVal) {
+				t.Errorf("unexpected result: got %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"strconv"
 	"sync"
+	"time"
 )
 
 func init() {
@@ -19,6 +21,22 @@ func init() {
 type d1Driver struct {
 	mu            sync.Mutex
 	clientFactory func(cfg *config) (CFD1Client, error)
+	options       []ClientOption // applied before any options from the DSN
+}
+
+// RegisterDriver registers an additional database/sql driver under name, whose
+// clients are created with opts, followed by any options given by the DSN. This
+// allows databases to be opened with different settings by driver name:
+//
+//	cfd1.RegisterDriver("cfd1-prod", cfd1.WithRequestTimeout(5*time.Second))
+//	cfd1.RegisterDriver("cfd1-staging", cfd1.WithDebugLogger(logger))
+//	db, err := sql.Open("cfd1-prod", "d1://account:token@db")
+//
+// Like [sql.Register], RegisterDriver panics if it is called twice with the
+// same name, including "cfd1", which is registered when the package is
+// imported.
+func RegisterDriver(name string, opts ...ClientOption) {
+	sql.Register(name, &d1Driver{options: append([]ClientOption(nil), opts...)})
 }
 
 // Open returns a new connection to the database.
@@ -36,16 +54,21 @@ func (d *d1Driver) OpenConnector(name string) (driver.Connector, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &connector{
+	c := &connector{
 		driver: d,
 		cfg:    cfg,
-	}, nil
+	}
+	if cfg.MaxConcurrent > 0 {
+		c.sem = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	return c, nil
 }
 
 // Connector implements driver.Connector interface.
 type connector struct {
 	driver *d1Driver
 	cfg    *config
+	sem    chan struct{} // shared by the connector's connections, if limited
 }
 
 func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
@@ -61,6 +84,7 @@ func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
 
 	newConn := &conn{
 		handle: h,
+		sem:    c.sem,
 	}
 	return newConn, nil
 }
@@ -74,13 +98,69 @@ func (d *d1Driver) createClient(cfg *config) (CFD1Client, error) {
 	if d.clientFactory != nil {
 		return d.clientFactory(cfg)
 	}
-	return NewClient(cfg.AccountID, cfg.APIToken), nil
+	opts := append(append([]ClientOption(nil), d.options...), cfg.Options...)
+	return NewClient(cfg.AccountID, cfg.APIToken, opts...), nil
 }
 
 type config struct {
 	AccountID          string
 	APIToken           string
 	DatabaseNameOrUUID string
+	Options            []ClientOption // from the config and other DSN parameters
+	MaxConcurrent      int            // from max_concurrent; 0 means no limit
+}
+
+var (
+	configsMu sync.RWMutex
+	configs   = make(map[string][]ClientOption)
+)
+
+// RegisterConfig registers a named set of client options for use by the
+// database/sql driver. A DSN with the query parameter config=name creates its
+// client with these options, followed by any options given by other DSN
+// parameters. Registering a name again replaces its options; connections
+// already opened are not affected.
+//
+// Example usage:
+//
+//	cfd1.RegisterConfig("prod", cfd1.WithRequestTimeout(5*time.Second))
+//	db, err := sql.Open("cfd1", "d1://account:token@db?config=prod")
+func RegisterConfig(name string, opts ...ClientOption) {
+	configsMu.Lock()
+	defer configsMu.Unlock()
+	configs[name] = opts
+}
+
+// parseDSNOptions returns the client options given by the query parameters of
+// a DSN.
+func parseDSNOptions(query url.Values) ([]ClientOption, error) {
+	var opts []ClientOption
+	if name := query.Get("config"); name != "" {
+		configsMu.RLock()
+		registered, ok := configs[name]
+		configsMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown config %q in DSN", name)
+		}
+		opts = append(opts, registered...)
+	}
+	for key, values := range query {
+		value := values[len(values)-1]
+		switch key {
+		case "config", "max_concurrent": // handled by parseDSN
+		case "timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout in DSN: %w", err)
+			}
+			opts = append(opts, WithRequestTimeout(d))
+		case "endpoint":
+			opts = append(opts, WithEndpoint(value))
+		default:
+			return nil, fmt.Errorf("unknown parameter %q in DSN", key)
+		}
+	}
+	return opts, nil
 }
 
 func parseDSN(dsn string) (*config, error) {
@@ -100,6 +180,19 @@ func parseDSN(dsn string) (*config, error) {
 	// Extract database_id from host
 	cfg.DatabaseNameOrUUID = u.Host
 
+	// Extract client options from query parameters
+	query := u.Query()
+	if cfg.Options, err = parseDSNOptions(query); err != nil {
+		return nil, err
+	}
+	if query.Has("max_concurrent") {
+		n, err := strconv.Atoi(query.Get("max_concurrent"))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid max_concurrent in DSN: %q", query.Get("max_concurrent"))
+		}
+		cfg.MaxConcurrent = n
+	}
+
 	// Validate the config
 	if cfg.AccountID == "" {
 		return nil, errors.New("account_id (username) is required in the DSN")
@@ -116,8 +209,55 @@ func parseDSN(dsn string) (*config, error) {
 
 type conn struct {
 	handle *Handle
+	sem    chan struct{} // limits concurrent queries, if not nil; see acquire
+	bad    bool          // set after a fatal error; see checkFatal
 }
 
+// acquire waits for a slot in the semaphore limiting the number of concurrent
+// queries made through c's connector, set with the max_concurrent DSN
+// parameter, and returns a function that releases it. It returns an error
+// wrapping ErrCanceled if ctx is done first.
+func (c *conn) acquire(ctx context.Context) (func(), error) {
+	if c.sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.sem <- struct{}{}:
+		return func() { <-c.sem }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: waiting for a connection slot: %w", ErrCanceled, ctx.Err())
+	}
+}
+
+// checkFatal returns err, after marking c as bad if err shows that c can no
+// longer be used: the API token was rejected, or the database no longer exists.
+// Such errors are returned wrapping driver.ErrBadConn as well as the original
+// error, so that database/sql discards the connection and retries on a new
+// one, whose connector looks the database up again. The request was rejected,
+// so retrying it cannot apply a statement twice.
+func (c *conn) checkFatal(err error) error {
+	var permErr *PermissionError
+	if err == nil || (!errors.As(err, &permErr) && !isNotFoundError(err)) {
+		return err
+	}
+	c.bad = true
+	return fmt.Errorf("%w: %w", driver.ErrBadConn, err)
+}
+
+// Optional database/sql driver interfaces implemented by the driver, which
+// database/sql and ORMs check for to determine its capabilities.
+var (
+	_ driver.DriverContext      = (*d1Driver)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+)
+
 func (c *conn) Prepare(query string) (driver.Stmt, error) {
 	return c.PrepareContext(context.Background(), query)
 }
@@ -134,12 +274,18 @@ func (c *conn) Close() error {
 	return nil
 }
 
+// errTxNotSupported is returned when beginning a transaction through the
+// driver. It wraps ErrNotSupported, so callers and ORMs probing for transaction
+// support can detect it with errors.Is.
+var errTxNotSupported = fmt.Errorf("cfd1: transactions cannot span requests to the D1 REST API; "+
+	"use BEGIN and COMMIT within a single multi-statement query: %w", ErrNotSupported)
+
 func (c *conn) Begin() (driver.Tx, error) {
-	return nil, driver.ErrSkip
+	return nil, errTxNotSupported
 }
 
 func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
-	return nil, driver.ErrSkip
+	return nil, errTxNotSupported
 }
 
 // Implement ExecerContext interface
@@ -147,15 +293,32 @@ func (c *conn) ExecContext(ctx context.Context, query string, args []driver.Name
 	if !c.IsValid() {
 		return nil, driver.ErrBadConn
 	}
-	params := namedValuesToAny(args)
-	_, err := c.handle.Query(ctx, query, params...)
+	release, err := c.acquire(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return &driverResult{
-		rowsAffected: int64(c.handle.LastMeta().RowsWritten),
-		lastInsertID: int64(c.handle.LastRowID()),
-	}, nil
+	defer release()
+	params := namedValuesToAny(args)
+	result, err := c.handle.rawQuery(ctx, query, params...)
+	if err != nil {
+		return nil, c.checkFatal(err)
+	}
+	return newDriverResult(resultMetas(result)), nil
+}
+
+// newDriverResult returns the result of executing a query whose statements
+// produced metas. A query may contain several statements, including BEGIN and
+// COMMIT, so the rows affected are totaled across all of them, and the last
+// insert ID is taken from the last statement that changed any rows.
+func newDriverResult(metas []QueryMeta) *driverResult {
+	var res driverResult
+	for _, meta := range metas {
+		res.rowsAffected += int64(meta.Changes)
+		if meta.Changes > 0 {
+			res.lastInsertID = int64(meta.LastRowID)
+		}
+	}
+	return &res
 }
 
 // Implement QueryerContext interface
@@ -163,35 +326,65 @@ func (c *conn) QueryContext(ctx context.Context, query string, args []driver.Nam
 	if !c.IsValid() {
 		return nil, driver.ErrBadConn
 	}
-	params := namedValuesToAny(args)
-	result, err := c.handle.Query(ctx, query, params...)
+	release, err := c.acquire(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
+	params := namedValuesToAny(args)
 
-	columns := make([]string, 0, len(result[0]))
-	for col := range result[0] {
-		columns = append(columns, col)
+	// The raw API is used so that columns are reported in the order of the
+	// query, which the map-based results of Handle.Query do not preserve.
+	result, err := c.handle.rawQuery(ctx, query, params...)
+	if err != nil {
+		return nil, c.checkFatal(err)
+	}
+	if len(result) == 0 {
+		return &rows{}, nil
 	}
 
 	return &rows{
-		columns: columns,
-		rows:    result,
+		columns: result[0].Results.Columns,
+		rows:    result[0].Results.Rows,
 	}, nil
 }
 
+// CheckNamedValue implements the NamedValueChecker interface, so that
+// arguments of types registered with RegisterType are encoded by the registered
+// function. Other arguments are converted by database/sql as usual.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	encode := typeEncoder(nv.Value)
+	if encode == nil {
+		return driver.ErrSkip
+	}
+	v, err := encode(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = v
+	return nil
+}
+
 // Implement Pinger interface
 func (c *conn) Ping(ctx context.Context) error {
-	return c.handle.Ping(ctx)
+	return c.checkFatal(c.handle.Ping(ctx))
 }
 
+// ResetSession reports driver.ErrBadConn for a connection marked bad by a
+// fatal error. Otherwise, there is no session state to reset, because no
+// connection to D1 is kept open between requests.
 func (c *conn) ResetSession(ctx context.Context) error {
-	// We don't keep connections open so this is a no-op
+	if c.bad {
+		return driver.ErrBadConn
+	}
 	return nil
 }
 
+// IsValid reports whether the connection can be returned to the pool. It is
+// false after a fatal error, such as a rejected API token or a deleted
+// database, so that database/sql opens a fresh connection instead.
 func (c *conn) IsValid() bool {
-	return c.handle != nil
+	return c.handle != nil && !c.bad
 }
 
 type stmt struct {
@@ -225,7 +418,7 @@ func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driv
 
 type rows struct {
 	columns []string
-	rows    []map[string]any
+	rows    [][]any
 	current int
 }
 
@@ -236,9 +429,8 @@ func (r *rows) Next(dest []driver.Value) error {
 	if r.current >= len(r.rows) {
 		return io.EOF
 	}
-	row := r.rows[r.current]
-	for i, col := range r.columns {
-		dest[i] = row[col]
+	for i, v := range r.rows[r.current] {
+		dest[i] = v
 	}
 	r.current++
 	return nil
@@ -263,7 +455,11 @@ func valuesToNamedValues(vals []driver.Value) []driver.NamedValue {
 func namedValuesToAny(nvs []driver.NamedValue) []any {
 	params := make([]any, len(nvs))
 	for i, nv := range nvs {
-		params[i] = nv.Value
+		if nv.Name != "" {
+			params[i] = Named(nv.Name, nv.Value)
+		} else {
+			params[i] = nv.Value
+		}
 	}
 	return params
 }
@@ -0,0 +1,317 @@
+package cfd1
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// openTestDB returns a *sql.DB using the cfd1 driver, whose requests are served
+// by handler. query, if given, is appended to the DSN as its query parameters.
+func openTestDB(t *testing.T, handler http.HandlerFunc, query ...string) *sql.DB {
+	t.Helper()
+	client := newTestClient(t, handler)
+	drv := &d1Driver{
+		clientFactory: func(cfg *config) (CFD1Client, error) { return client, nil },
+	}
+	dsn := "d1://account:token@e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f"
+	if len(query) > 0 {
+		dsn += "?" + strings.Join(query, "&")
+	}
+	conn, err := drv.OpenConnector(dsn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	db := sql.OpenDB(conn)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestDriverColumnOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		columns []string
+		rows    [][]any
+	}{
+		{
+			"Table-valued function",
+			"SELECT value, value * 2 AS doubled FROM generate_series(1, 3)",
+			[]string{"value", "doubled"},
+			[][]any{{int64(1), int64(2)}, {int64(2), int64(4)}, {int64(3), int64(6)}},
+		},
+		{
+			"CTE with named columns",
+			"WITH t(z, y, x) AS (VALUES (1, 2, 3)) SELECT z, y, x FROM t",
+			[]string{"z", "y", "x"},
+			[][]any{{int64(1), int64(2), int64(3)}},
+		},
+		{
+			"No rows",
+			"SELECT b, a FROM empty",
+			[]string{"b", "a"},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t, func(w http.ResponseWriter, r *http.Request) {
+				var req rawQueryRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				if req.SQL != tt.query {
+					t.Errorf("unexpected query: %s", req.SQL)
+				}
+				writeAPIResult(w, []RawQueryResult{rawResult(tt.columns, tt.rows...)}, nil)
+			})
+
+			rows, err := db.Query(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer rows.Close()
+
+			cols, err := rows.Columns()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cols, tt.columns) {
+				t.Errorf("unexpected columns: got %v, want %v", cols, tt.columns)
+			}
+
+			var got [][]any
+			for rows.Next() {
+				row := make([]any, len(cols))
+				ptrs := make([]any, len(cols))
+				for i := range row {
+					ptrs[i] = &row[i]
+				}
+				if err := rows.Scan(ptrs...); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got = append(got, row)
+			}
+			if !reflect.DeepEqual(got, tt.rows) {
+				t.Errorf("unexpected rows: got %v, want %v", got, tt.rows)
+			}
+		})
+	}
+}
+
+func TestParseDSNOptions(t *testing.T) {
+	RegisterConfig("test-config", WithListConcurrency(4), WithRequestTimeout(time.Minute))
+
+	tests := []struct {
+		name        string
+		dsn         string
+		timeout     time.Duration
+		endpoint    string
+		concurrency int
+		wantErr     bool
+	}{
+		{"Default", "d1://a:t@db", defaultHttpTimeout, defaultCloudflareBaseURL, 0, false},
+		{"Timeout and endpoint", "d1://a:t@db?timeout=5s&endpoint=http://localhost:8787/", 5 * time.Second, "http://localhost:8787", 0, false},
+		{"Config", "d1://a:t@db?config=test-config", time.Minute, defaultCloudflareBaseURL, 4, false},
+		{"Config overridden", "d1://a:t@db?config=test-config&timeout=1s", time.Second, defaultCloudflareBaseURL, 4, false},
+		{"Unknown config", "d1://a:t@db?config=missing", 0, "", 0, true},
+		{"Invalid timeout", "d1://a:t@db?timeout=soon", 0, "", 0, true},
+		{"Unknown parameter", "d1://a:t@db?timout=5s", 0, "", 0, true},
+		{"Max concurrent", "d1://a:t@db?max_concurrent=3", defaultHttpTimeout, defaultCloudflareBaseURL, 0, false},
+		{"Invalid max concurrent", "d1://a:t@db?max_concurrent=0", 0, "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseDSN(tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+			c := NewClient(cfg.AccountID, cfg.APIToken, cfg.Options...)
+			if c.httpClient.Timeout != tt.timeout || c.baseURL != tt.endpoint || c.listConcurrency != tt.concurrency {
+				t.Errorf("got timeout %v, endpoint %q, concurrency %d; want %v, %q, %d",
+					c.httpClient.Timeout, c.baseURL, c.listConcurrency, tt.timeout, tt.endpoint, tt.concurrency)
+			}
+		})
+	}
+}
+
+// registeredDrivers numbers the drivers registered by TestRegisterDriver, so
+// that each run of the test, as with -count, registers a new name.
+var registeredDrivers atomic.Int32
+
+func TestRegisterDriver(t *testing.T) {
+	var queried bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queried = true
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1.0})}, nil)
+	}))
+	t.Cleanup(srv.Close)
+	name := fmt.Sprintf("cfd1-test-%d", registeredDrivers.Add(1))
+	RegisterDriver(name, WithEndpoint(srv.URL))
+
+	db, err := sql.Open(name, "d1://account:token@e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	var x int
+	if err := db.QueryRow("SELECT 1 AS x").Scan(&x); err != nil || x != 1 {
+		t.Errorf("got (%d, %v), want (1, nil)", x, err)
+	}
+	if !queried {
+		t.Error("driver did not use the registered endpoint")
+	}
+}
+
+func TestDriverExecTransaction(t *testing.T) {
+	db := openTestDB(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		results := []RawQueryResult{rawResult(nil), rawResult(nil), rawResult(nil), rawResult(nil)}
+		results[1].Meta = QueryMeta{Changes: 1, RowsWritten: 2, LastRowID: 10}
+		results[2].Meta = QueryMeta{Changes: 2, RowsWritten: 4, LastRowID: 12}
+		results[3].Meta = QueryMeta{LastRowID: 12}
+		writeAPIResult(w, results, nil)
+	})
+
+	res, err := db.Exec("BEGIN; INSERT INTO t VALUES (?); INSERT INTO t VALUES (?), (?); COMMIT;", 1, 2, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n != 3 {
+		t.Errorf("unexpected RowsAffected: got %d, want 3", n)
+	}
+	if id, _ := res.LastInsertId(); id != 12 {
+		t.Errorf("unexpected LastInsertId: got %d, want 12", id)
+	}
+}
+
+func TestDriverNamedArgs(t *testing.T) {
+	var req rawQueryRequest
+	db := openTestDB(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1.0})}, nil)
+	})
+
+	var x int
+	err := db.QueryRow("SELECT :a + @b", sql.Named("b", 2), sql.Named("a", 1)).Scan(&x)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "SELECT ?1 + ?2"; req.SQL != want || !reflect.DeepEqual(req.Params, []any{1.0, 2.0}) {
+		t.Errorf("sent %q %v, want %q [1 2]", req.SQL, req.Params, want)
+	}
+}
+
+func TestDriverMaxConcurrent(t *testing.T) {
+	var active, maxActive atomic.Int32
+	release := make(chan struct{})
+	db := openTestDB(t, func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for m := maxActive.Load(); n > m && !maxActive.CompareAndSwap(m, n); m = maxActive.Load() {
+		}
+		<-release
+		writeAPIResult(w, []RawQueryResult{rawResult(nil)}, nil)
+	}, "max_concurrent=2")
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := db.Exec("INSERT INTO t VALUES (1)"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	// With both slots taken, a query waits until its context is done
+	for active.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := db.QueryContext(ctx, "SELECT 1")
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected ErrCanceled wrapping context.DeadlineExceeded, got %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+	if n := maxActive.Load(); n != 2 {
+		t.Errorf("unexpected maximum concurrent requests: got %d, want 2", n)
+	}
+}
+
+func TestDriverBeginNotSupported(t *testing.T) {
+	db := openTestDB(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"1"}, []any{1.0})}, nil)
+	})
+
+	_, err := db.Begin()
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestDriverBadConn(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		code    int
+		wantBad bool
+	}{
+		{"Authentication error", http.StatusUnauthorized, 10000, true},
+		{"Database not found", http.StatusNotFound, notFoundErrorCode, true},
+		{"SQLite error", http.StatusBadRequest, 7500, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			db := openTestDB(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					writeAPIError(w, tt.status, tt.code, "failed")
+					return
+				}
+				writeAPIResult(w, []RawQueryResult{rawResult([]string{"1"}, []any{1.0})}, nil)
+			})
+
+			// A fatal error discards the connection, and database/sql retries
+			// the query on a new one.
+			_, err := db.Exec("SELECT 1")
+			if tt.wantBad {
+				if err != nil || requests != 2 {
+					t.Errorf("expected retry to succeed, got %v after %d requests", err, requests)
+				}
+			} else if err == nil || errors.Is(err, driver.ErrBadConn) || requests != 1 {
+				t.Errorf("expected error without retry, got %v after %d requests", err, requests)
+			}
+		})
+	}
+
+	t.Run("Persistent error", func(t *testing.T) {
+		db := openTestDB(t, func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(w, http.StatusUnauthorized, 10000, "Authentication error")
+		})
+		_, err := db.Exec("SELECT 1")
+		if !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("expected ErrPermissionDenied, got %v", err)
+		}
+	})
+}
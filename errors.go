@@ -3,6 +3,9 @@ package cfd1
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -14,10 +17,102 @@ var ErrSQLite = errors.New("SQLite error")
 // exist.
 var ErrNotFound = errors.New("database not found")
 
+// ErrCanceled is returned within a wrapped error when a request to the D1 API is
+// abandoned because its context was canceled or its deadline was exceeded. The
+// error also wraps the context's error, so errors.Is with context.Canceled or
+// context.DeadlineExceeded can be used to tell the two cases apart.
+//
+// The D1 API provides no way to abort a query or other operation on the server,
+// so canceling only stops the client waiting for the response. A query that
+// was already sent may still run to completion, and any changes it makes are
+// kept. If it was a write, check its effects before repeating it.
+var ErrCanceled = errors.New("request canceled")
+
+// ErrDatabaseFull is returned within a wrapped error if a write fails because
+// the database has reached D1's maximum database size. Errors returned by
+// [Handle] methods are a [DatabaseFullError] that reports the database size.
+var ErrDatabaseFull = errors.New("database full")
+
+// ErrInvalidUTF8 is returned within a wrapped error if a query or one of its
+// string parameters is not valid UTF-8, and the client was created with
+// [WithUTF8Handling] set to [UTF8Reject].
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+// ErrTooManyRows is returned within a [TooManyRowsError] if a query returns
+// more rows than the limit set with [WithMaxResultRows].
+var ErrTooManyRows = errors.New("too many rows")
+
+// ErrTooManyParams is returned within a [TooManyParamsError] if a query binds
+// more parameters than D1 allows.
+var ErrTooManyParams = errors.New("too many parameters")
+
+// ErrMixedParams is returned within a wrapped error if a query is given both
+// named and positional parameters, or named parameters for a query with
+// positional placeholders. See [Named].
+var ErrMixedParams = errors.New("mixed named and positional parameters")
+
+// ErrQueryNotAllowed is returned within a wrapped error, without sending the
+// query, if the client was created with [WithQueryAllowlist] and the hash of a
+// query is not in the allowlist.
+var ErrQueryNotAllowed = errors.New("query not in allowlist")
+
+// ErrQueryTooLarge is returned within a [QueryTooLargeError] if the SQL text of a
+// query is larger than D1 accepts.
+var ErrQueryTooLarge = errors.New("query too large")
+
+// ErrTooManyResultSets is returned within a wrapped error if a query has more
+// statements, or returns more result sets, than the limit set with
+// [WithMaxResultSets].
+var ErrTooManyResultSets = errors.New("too many result sets")
+
+// ErrReadOnly is returned within a wrapped error if a query that may modify
+// the database, or another write operation, is attempted through a read-only
+// handle returned by [Handle.ReadReplica]. The query is not sent.
+var ErrReadOnly = errors.New("write through read-only handle")
+
+// ErrDeadlineTooShort is returned within a wrapped error if an import or export
+// is not started because its context's deadline is sooner than the minimum set
+// with [WithMinOperationDeadline].
+var ErrDeadlineTooShort = errors.New("context deadline too short")
+
+// ErrNotSupported is returned within a wrapped error by methods for operations
+// that the D1 API does not currently provide.
+var ErrNotSupported = errors.New("operation not supported by the D1 API")
+
+// ErrPermissionDenied is returned within a wrapped error if the API token is not
+// authorized to perform an operation, or if SQLite denies access to an object
+// with SQLITE_AUTH or SQLITE_PERM. Use errors.As with a [PermissionError] for
+// details about a rejected API request.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// permissionErrorCodes are Cloudflare API error codes indicating that the API
+// token is not authorized for the requested operation.
+var permissionErrorCodes = map[int]bool{
+	7403:  true, // account not valid or not authorized to access this service
+	9109:  true, // unauthorized to access requested resource
+	10000: true, // authentication error
+}
+
+// notFoundErrorCode is the Cloudflare API error code for a database that does
+// not exist.
+const notFoundErrorCode = 7404
+
+// isNotFoundError reports whether err is an API error indicating that the
+// requested database does not exist.
+func isNotFoundError(err error) bool {
+	var d1Err *D1Error
+	return errors.As(err, &d1Err) && (d1Err.Code == notFoundErrorCode || d1Err.StatusCode == http.StatusNotFound)
+}
+
 // D1Error represents an error returned by the D1 API other than an [ErrSQLite].
+// Code is the API-level error code, and StatusCode is the HTTP status code of
+// the response that carried the error, or 0 if the error was not returned by
+// the API. For 5xx responses, which may not contain a decodable API error, Code
+// and StatusCode are both the HTTP status code, and Message is the body.
 type D1Error struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	StatusCode int    `json:"-"`
 }
 
 func newD1Error(code int, message string) *D1Error {
@@ -32,6 +127,9 @@ func (e *D1Error) Error() string {
 }
 
 func (e *D1Error) Is(target error) bool {
+	if target == ErrDatabaseFull {
+		return isDatabaseFullMessage(e.Message)
+	}
 	t, ok := target.(*D1Error)
 	if !ok {
 		return false
@@ -42,11 +140,19 @@ func (e *D1Error) Is(target error) bool {
 // SQLiteError represents a syntax error returned when executing a query. It
 // contains the error message, the query that caused the error, the query
 // bindings, and the SQLite error code such as SQLITE_AUTH or SQLITE_ERROR.
+//
+// If the client was created with [WithBindingHints] and the error is a
+// constraint failure naming one or more columns, Columns holds those column
+// names, and RelatedBindings holds the 0-based indexes of any bindings that
+// were likely bound to them. These are best-effort guesses from the text of the
+// query, and are mentioned in the error string to help with debugging.
 type SQLiteError struct {
-	Message    string
-	Query      string
-	Bindings   []any
-	SQLiteCode string
+	Message         string
+	Query           string
+	Bindings        []any
+	SQLiteCode      string
+	Columns         []string
+	RelatedBindings []int
 }
 
 func newSQLiteError(message, query string, bindings []any, sqliteCode string) *SQLiteError {
@@ -59,13 +165,288 @@ func newSQLiteError(message, query string, bindings []any, sqliteCode string) *S
 }
 
 func (e *SQLiteError) Error() string {
-	return fmt.Sprintf("%s: %s", e.Message, e.SQLiteCode)
+	msg := fmt.Sprintf("%s: %s", e.Message, e.SQLiteCode)
+	if len(e.RelatedBindings) == 0 {
+		return msg
+	}
+
+	hints := make([]string, len(e.RelatedBindings))
+	for i, idx := range e.RelatedBindings {
+		if idx < len(e.Bindings) {
+			hints[i] = fmt.Sprintf("binding #%d (value %#v)", idx+1, e.Bindings[idx])
+		} else {
+			hints[i] = fmt.Sprintf("binding #%d (missing)", idx+1)
+		}
+	}
+	return fmt.Sprintf("%s (%s likely related)", msg, strings.Join(hints, ", "))
 }
 
 func (e *SQLiteError) Is(target error) bool {
+	switch target {
+	case ErrPermissionDenied:
+		return e.SQLiteCode == "SQLITE_AUTH" || e.SQLiteCode == "SQLITE_PERM"
+	case ErrDatabaseFull:
+		return e.SQLiteCode == "SQLITE_FULL" || isDatabaseFullMessage(e.Message)
+	}
 	return target == ErrSQLite
 }
 
+// isDatabaseFullMessage reports whether an error message from D1 indicates
+// that the database has reached its maximum size.
+func isDatabaseFullMessage(message string) bool {
+	return strings.Contains(strings.ToLower(message), "maximum db size")
+}
+
+// DatabaseFullError is returned by [Handle] methods when a write fails because
+// the database has reached D1's maximum database size. SizeBytes is the size of
+// the database reported by the handle's most recent successful query, or zero
+// if it is not known. It matches [ErrDatabaseFull] with errors.Is.
+type DatabaseFullError struct {
+	SizeBytes int
+	Err       error
+}
+
+func (e *DatabaseFullError) Error() string {
+	if e.SizeBytes > 0 {
+		return fmt.Sprintf("database full at %d bytes: %v", e.SizeBytes, e.Err)
+	}
+	return fmt.Sprintf("database full: %v", e.Err)
+}
+
+func (e *DatabaseFullError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DatabaseFullError) Is(target error) bool {
+	return target == ErrDatabaseFull
+}
+
+// TooManyRowsError is returned when a query returns more rows than the limit
+// set with [WithMaxResultRows]. It matches [ErrTooManyRows] with errors.Is.
+type TooManyRowsError struct {
+	MaxRows int
+}
+
+func (e *TooManyRowsError) Error() string {
+	return fmt.Sprintf("query returned more than %d rows", e.MaxRows)
+}
+
+func (e *TooManyRowsError) Is(target error) bool {
+	return target == ErrTooManyRows
+}
+
+// TooManyParamsError is returned, without sending the query, when a query
+// binds more parameters than the limit of [MaxQueryParams], or the limit set
+// with [WithMaxQueryParams]. Count is the number of parameters the query binds,
+// and Max is the limit. It matches [ErrTooManyParams] with errors.Is.
+type TooManyParamsError struct {
+	Count int
+	Max   int
+}
+
+func (e *TooManyParamsError) Error() string {
+	return fmt.Sprintf("%d params exceeds D1 limit of %d; consider chunking the query, for example with QueryIn",
+		e.Count, e.Max)
+}
+
+func (e *TooManyParamsError) Is(target error) bool {
+	return target == ErrTooManyParams
+}
+
+// QueryTooLargeError is returned, without sending the query, when the SQL text
+// of a query is larger than [MaxQuerySize]. Size is the size of the query in
+// bytes, and Max is the limit. It matches [ErrQueryTooLarge] with errors.Is.
+type QueryTooLargeError struct {
+	Size int
+	Max  int
+}
+
+func (e *QueryTooLargeError) Error() string {
+	return fmt.Sprintf("query of %d bytes exceeds D1 limit of %d bytes", e.Size, e.Max)
+}
+
+func (e *QueryTooLargeError) Is(target error) bool {
+	return target == ErrQueryTooLarge
+}
+
+// ImportFileError is returned by [Client.Import] and [Handle.Import] when the
+// SQL file to import cannot be read, such as when it does not exist or its
+// permissions do not allow reading it. This distinguishes a bad path from a
+// failure of the import itself. Err is the underlying error from the
+// filesystem, so errors.Is can be used with [io/fs.ErrNotExist] or
+// [io/fs.ErrPermission].
+type ImportFileError struct {
+	Path string
+	Err  error
+}
+
+func (e *ImportFileError) Error() string {
+	return fmt.Sprintf("reading import file %s: %v", e.Path, e.Err)
+}
+
+func (e *ImportFileError) Unwrap() error {
+	return e.Err
+}
+
+// regexConstraintColumns matches the columns named in an SQLite constraint
+// failure message, such as "UNIQUE constraint failed: users.email".
+var regexConstraintColumns = regexp.MustCompile(`constraint failed: ([\w$]+\.[\w$]+(?:, [\w$]+\.[\w$]+)*)`)
+
+// addBindingHints fills in e.Columns and e.RelatedBindings by correlating the
+// columns named in a constraint failure message with the placeholders bound to
+// them in e.Query. Placeholders are found in INSERT column and value lists, and
+// in "column = ?" expressions such as those in an UPDATE statement.
+func (e *SQLiteError) addBindingHints() {
+	m := regexConstraintColumns.FindStringSubmatch(e.Message)
+	if m == nil {
+		return
+	}
+	e.Columns = nil
+	for _, qualified := range strings.Split(m[1], ", ") {
+		e.Columns = append(e.Columns, qualified[strings.LastIndexByte(qualified, '.')+1:])
+	}
+
+	tokens := tokenizeSQL(e.Query)
+	numbers := paramNumbers(tokens)
+	seen := make(map[int]bool)
+	e.RelatedBindings = nil
+	for _, col := range e.Columns {
+		for _, n := range append(insertedBindings(tokens, numbers, col), assignedBindings(tokens, numbers, col)...) {
+			if n > 0 && !seen[n] {
+				seen[n] = true
+				e.RelatedBindings = append(e.RelatedBindings, n-1)
+			}
+		}
+	}
+	sort.Ints(e.RelatedBindings)
+}
+
+// insertedBindings returns the parameter numbers of placeholders that supply
+// values for column in the INSERT statements among tokens.
+func insertedBindings(tokens []sqlToken, numbers []int, column string) []int {
+	var result []int
+	for i := 0; i < len(tokens); i++ {
+		if !tokens[i].is("INSERT") && !tokens[i].is("REPLACE") {
+			continue
+		}
+
+		// Find the column list and the position of column within it
+		j := i + 1
+		for j < len(tokens) && !tokens[j].is("(") && !tokens[j].is("VALUES") && tokens[j].kind != tokenSemicolon {
+			j++
+		}
+		if j >= len(tokens) || !tokens[j].is("(") {
+			continue
+		}
+		colIndex, item := -1, 0
+		for j++; j < len(tokens) && !tokens[j].is(")"); j++ {
+			if tokens[j].is(",") {
+				item++
+			} else if strings.EqualFold(unquoteIdent(tokens[j]), column) {
+				colIndex = item
+			}
+		}
+		if j++; colIndex < 0 || j >= len(tokens) || !tokens[j].is("VALUES") {
+			continue
+		}
+
+		// Walk the value tuples, checking the item at colIndex in each
+		depth, itemStart := 0, 0
+		check := func(end int) {
+			if item == colIndex && end-itemStart == 1 && tokens[itemStart].kind == tokenParam {
+				result = append(result, numbers[itemStart])
+			}
+		}
+		for j++; j < len(tokens) && tokens[j].kind != tokenSemicolon; j++ {
+			t := tokens[j]
+			if depth == 0 && !t.is("(") && !t.is(",") {
+				break // end of VALUES, such as ON CONFLICT or RETURNING
+			}
+			switch {
+			case t.is("("):
+				depth++
+				if depth == 1 {
+					item, itemStart = 0, j+1
+				}
+			case t.is(")"):
+				if depth == 1 {
+					check(j)
+				}
+				depth--
+			case t.is(",") && depth == 1:
+				check(j)
+				item, itemStart = item+1, j+1
+			}
+		}
+		i = j
+	}
+	return result
+}
+
+// assignedBindings returns the parameter numbers of placeholders that appear in
+// "column = ?" expressions among tokens.
+func assignedBindings(tokens []sqlToken, numbers []int, column string) []int {
+	var result []int
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].kind != tokenWord && tokens[i].kind != tokenQuotedIdent {
+			continue
+		}
+		if strings.EqualFold(unquoteIdent(tokens[i]), column) && tokens[i+1].is("=") && tokens[i+2].kind == tokenParam {
+			result = append(result, numbers[i+2])
+		}
+	}
+	return result
+}
+
+// PermissionError is returned when the D1 API rejects a request because the API
+// token lacks the required permissions. It wraps the underlying [D1Error], and
+// matches [ErrPermissionDenied] with errors.Is.
+//
+// WriteDenied is set when the rejected request would have created, deleted, or
+// otherwise modified a database outside of a query, such as an import or
+// export. This is typical of a token scoped with D1 read permissions only,
+// which can list and inspect databases but not change them.
+type PermissionError struct {
+	Method      string
+	Path        string
+	StatusCode  int
+	WriteDenied bool
+	Err         *D1Error
+}
+
+func (e *PermissionError) Error() string {
+	if e.WriteDenied {
+		return fmt.Sprintf("permission denied for %s %s (token may be read-only): %v", e.Method, e.Path, e.Err)
+	}
+	return fmt.Sprintf("permission denied for %s %s: %v", e.Method, e.Path, e.Err)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PermissionError) Is(target error) bool {
+	return target == ErrPermissionDenied
+}
+
+// convertPermissionError converts a [D1Error] returned for the request with the
+// given method, path, and HTTP status code into a [PermissionError] if it
+// indicates missing permissions. Otherwise, it returns the original error.
+func convertPermissionError(d1Err *D1Error, method, path string, statusCode int) error {
+	if statusCode != http.StatusUnauthorized && statusCode != http.StatusForbidden &&
+		!permissionErrorCodes[d1Err.Code] {
+		return d1Err
+	}
+	isQuery := strings.HasSuffix(path, "/query") || strings.HasSuffix(path, "/raw")
+	return &PermissionError{
+		Method:      method,
+		Path:        path,
+		StatusCode:  statusCode,
+		WriteDenied: method != http.MethodGet && !isQuery,
+		Err:         d1Err,
+	}
+}
+
 // convertSQLiteError converts a [D1Error] to a more-specific [SQLiteError] if
 // it is appropriate. Otherwise, it returns the original error.
 func convertSQLiteError(err error, query string, bindings []any) error {
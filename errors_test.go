@@ -0,0 +1,92 @@
+package cfd1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestPermissionError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusForbidden, 10000, "Authentication error")
+	})
+	ctx := context.Background()
+
+	err := client.DeleteDatabase(ctx, "11111111-2222-3333-4444-555555555555")
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected ErrPermissionDenied, got %v", err)
+	}
+	var permErr *PermissionError
+	if !errors.As(err, &permErr) {
+		t.Fatalf("expected PermissionError, got %T", err)
+	}
+	if !permErr.WriteDenied {
+		t.Errorf("expected WriteDenied for DELETE")
+	}
+	if permErr.StatusCode != http.StatusForbidden || permErr.Err.Code != 10000 {
+		t.Errorf("unexpected details: status %d, code %d", permErr.StatusCode, permErr.Err.Code)
+	}
+
+	_, err = client.Query(ctx, "11111111-2222-3333-4444-555555555555", "SELECT 1")
+	if !errors.As(err, &permErr) {
+		t.Fatalf("expected PermissionError, got %T", err)
+	}
+	if permErr.WriteDenied {
+		t.Errorf("unexpected WriteDenied for query")
+	}
+}
+
+func TestSQLiteErrorPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected bool
+	}{
+		{"SQLITE_AUTH", "SQLITE_AUTH", true},
+		{"SQLITE_PERM", "SQLITE_PERM", true},
+		{"SQLITE_ERROR", "SQLITE_ERROR", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := convertSQLiteError(newD1Error(7500, "not authorized: "+tt.code), "SELECT 1", nil)
+			if !errors.Is(err, ErrSQLite) {
+				t.Errorf("expected ErrSQLite, got %v", err)
+			}
+			if got := errors.Is(err, ErrPermissionDenied); got != tt.expected {
+				t.Errorf("unexpected errors.Is(ErrPermissionDenied): got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDatabaseFullError(t *testing.T) {
+	var calls int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			rs := rawResult([]string{"x"}, []any{1})
+			rs.Meta.SizeAfter = 9_999_000_000
+			writeAPIResult(w, []RawQueryResult{rs}, nil)
+			return
+		}
+		writeAPIError(w, http.StatusBadRequest, 7500, "Exceeded maximum DB size: SQLITE_FULL")
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	if err := h.QueryRow(context.Background(), "SELECT 1").Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := h.Execute(context.Background(), "INSERT INTO t VALUES (1)")
+	if !errors.Is(err, ErrDatabaseFull) {
+		t.Fatalf("expected ErrDatabaseFull, got %v", err)
+	}
+	var fullErr *DatabaseFullError
+	if !errors.As(err, &fullErr) || fullErr.SizeBytes != 9_999_000_000 {
+		t.Errorf("expected DatabaseFullError with size, got %v", err)
+	}
+	if !errors.Is(err, ErrSQLite) {
+		t.Errorf("expected wrapped SQLiteError, got %v", err)
+	}
+}
@@ -6,16 +6,50 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // ExportOptions represents the options for exporting a D1 database.
+//
+// If IncludeDependencies is set and Tables is not empty, the foreign keys of
+// the database are inspected before the export. Any tables referenced by the
+// listed tables, directly or indirectly, are added to the export, and the
+// tables are ordered so that referenced tables come before the tables that
+// refer to them. This allows the resulting dump to be imported with foreign
+// key enforcement enabled.
+//
+// If TablesQuery is set, it is executed before the export, with the parameters
+// in TablesQueryParams, and the values in the first column of its result are
+// added to Tables. This allows the tables to export to be selected by a query,
+// such as one reading a metadata table of tables modified since a given date.
+// Each name must be an existing table, or the export fails without being
+// started. If the query returns no names and Tables is empty, the export also
+// fails, rather than exporting every table.
+//
+// Format selects the format of the export file. The D1 API currently only
+// produces SQL text dumps, so requesting [FormatSQLite] fails with an error
+// matching [ErrNotSupported].
 type ExportOptions struct {
-	NoData   bool     `json:"no_data"`          // Export only table definitions, not contents
-	NoSchema bool     `json:"no_schema"`        // Export only table contents, not definitions
-	Tables   []string `json:"tables,omitempty"` // Tables to export; if empty, all tables are exported
+	NoData              bool         `json:"no_data"`          // Export only table definitions, not contents
+	NoSchema            bool         `json:"no_schema"`        // Export only table contents, not definitions
+	Tables              []string     `json:"tables,omitempty"` // Tables to export; if empty, all tables are exported
+	IncludeDependencies bool         `json:"-"`                // Add and order tables referenced by foreign keys
+	TablesQuery         string       `json:"-"`                // Query whose first column lists more tables to export
+	TablesQueryParams   []any        `json:"-"`                // Parameters bound to TablesQuery
+	Format              ExportFormat `json:"-"`                // Format of the export file; defaults to FormatSQL
 }
 
+// ExportFormat is the file format of a database export.
+type ExportFormat string
+
+const (
+	FormatSQL    ExportFormat = ""       // A text file of SQL statements (the default)
+	FormatSQLite ExportFormat = "sqlite" // A binary SQLite database file; not currently supported by D1
+)
+
 // ExportResponse represents the API response for export operations.
 type exportResponse struct {
 	Success    bool     `json:"success"`
@@ -65,6 +99,9 @@ type exportResponse struct {
 //	}
 //	fmt.Printf("Database export complete. Download URL: %s\n", downloadURL)
 func (c *Client) Export(ctx context.Context, databaseID string, opts *ExportOptions) (string, error) {
+	if err := c.checkOperationDeadline(ctx, "export"); err != nil {
+		return "", err
+	}
 	path := fmt.Sprintf("/database/%s/export", databaseID)
 	if opts == nil {
 		opts = &ExportOptions{} // default to export everything
@@ -72,6 +109,30 @@ func (c *Client) Export(ctx context.Context, databaseID string, opts *ExportOpti
 	if opts.NoData && opts.NoSchema {
 		return "", newD1Error(99999, "cannot export with both no_data and no_schema")
 	}
+	if opts.Format != FormatSQL {
+		return "", fmt.Errorf("export format %q: %w", opts.Format, ErrNotSupported)
+	}
+	if opts.TablesQuery != "" {
+		tables, err := c.queryExportTables(ctx, databaseID, opts.TablesQuery, opts.TablesQueryParams)
+		if err != nil {
+			return "", fmt.Errorf("selecting tables to export: %w", err)
+		}
+		withQueried := *opts
+		withQueried.Tables = append(slices.Clip(opts.Tables), tables...)
+		if len(withQueried.Tables) == 0 {
+			return "", fmt.Errorf("selecting tables to export: query returned no tables")
+		}
+		opts = &withQueried
+	}
+	if opts.IncludeDependencies && len(opts.Tables) > 0 {
+		tables, err := c.resolveTableDependencies(ctx, databaseID, opts.Tables)
+		if err != nil {
+			return "", fmt.Errorf("resolving table dependencies: %w", err)
+		}
+		withDeps := *opts
+		withDeps.Tables = tables
+		opts = &withDeps
+	}
 
 	body := struct {
 		OutputFormat string         `json:"output_format"`
@@ -153,30 +214,323 @@ func (c *Client) pollExportStatus(ctx context.Context, path, bookmark string) (s
 	}
 }
 
+// queryExportTables executes sql with params, and returns the values in the
+// first column of its result, which must be the names of tables in the
+// database. Names are returned as spelled in the schema.
+func (c *Client) queryExportTables(ctx context.Context, databaseID, sql string, params []any) ([]string, error) {
+	result, err := c.RawQuery(ctx, databaseID, sql, params...)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 || len(result[0].Results.Rows) == 0 {
+		return nil, nil
+	}
+
+	schema, err := c.RawQuery(WithoutDefaultLimit(ctx), databaseID, "SELECT name FROM sqlite_master WHERE type = 'table'")
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]string)
+	if len(schema) > 0 {
+		for _, row := range schema[0].Results.Rows {
+			name, _ := row[0].(string)
+			known[strings.ToLower(name)] = name
+		}
+	}
+
+	var tables []string
+	for i, row := range result[0].Results.Rows {
+		name, ok := row[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("row %d: table name %v is not a string", i, row[0])
+		}
+		table, ok := known[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("row %d: no such table: %q", i, name)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// resolveTableDependencies returns tables, along with all tables they reference
+// through foreign keys, ordered so that referenced tables come first.
+func (c *Client) resolveTableDependencies(ctx context.Context, databaseID string, tables []string) ([]string, error) {
+	result, err := c.RawQuery(WithoutDefaultLimit(ctx), databaseID, `SELECT m.name, p."table" FROM sqlite_master m `+
+		`JOIN pragma_foreign_key_list(m.name) p WHERE m.type = 'table'`)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[string][]string)
+	if len(result) > 0 {
+		for _, row := range result[0].Results.Rows {
+			child, _ := row[0].(string)
+			parent, _ := row[1].(string)
+			parents[child] = append(parents[child], parent)
+		}
+	}
+	return orderTablesByDependency(tables, parents), nil
+}
+
+// orderTablesByDependency returns tables and their transitive parents from the
+// parents map, ordered so that each table follows its parents. Tables are
+// otherwise kept in the order given. Self-references and cycles are ignored.
+func orderTablesByDependency(tables []string, parents map[string][]string) []string {
+	var ordered []string
+	state := make(map[string]int) // 0 = unvisited, 1 = visiting, 2 = done
+
+	var visit func(table string)
+	visit = func(table string) {
+		if state[table] != 0 {
+			return
+		}
+		state[table] = 1
+		for _, parent := range parents[table] {
+			visit(parent)
+		}
+		state[table] = 2
+		ordered = append(ordered, table)
+	}
+	for _, table := range tables {
+		visit(table)
+	}
+	return ordered
+}
+
 // SaveExportToDisk is a helper function that downloads an export from the given
 // URL and saves it to the specified location on disk. It returns an error if
-// the download fails or the file cannot be written.
+// the download fails or the file cannot be written. It is equivalent to
+// [DownloadExport] without a context or progress callback. Prefer
+// [Client.DownloadExport], which can be canceled and uses the client's HTTP
+// transport, including any proxy and TLS settings.
 func SaveExportToDisk(url, filename string) error {
-	resp, err := http.Get(url)
+	return DownloadExport(context.Background(), url, filename, nil)
+}
+
+// exportDownloadAttempts is the number of times DownloadExport tries to
+// complete a download before giving up.
+const exportDownloadAttempts = 5
+
+// DownloadProgress is called by [DownloadExport] as data is received, with the
+// number of bytes written to the file so far, and the total size of the
+// download, or -1 if it is not known.
+type DownloadProgress func(written, total int64)
+
+// DownloadExport downloads an export from the given URL, as returned by
+// [Client.Export], and saves it to filename, as described for the package-level
+// [DownloadExport] function. The download is made with the client's HTTP
+// transport, so proxy, TLS, and other transport settings configured with
+// [WithHTTPClient] apply to it. Downloads bypass [WithDebugLogger],
+// [WithRecorder], and [WithReplay], which hold each response body in memory,
+// so they are not logged, recorded, or replayed; with [WithReplay], the export
+// is downloaded with [http.DefaultTransport]. The client's request timeout
+// does not apply, since an export of a large database can take much longer to
+// download than an API request takes; use ctx to limit the download time
+// instead.
+func (c *Client) DownloadExport(ctx context.Context, url, filename string, progress DownloadProgress) error {
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	httpClient.Transport = downloadTransport(httpClient.Transport)
+	return downloadExport(ctx, &httpClient, url, filename, progress)
+}
+
+// downloadTransport returns rt without the transports added by WithDebugLogger
+// and WithRecorder, which read each response body into memory, or nil, to use
+// the default transport, in place of the transport added by WithReplay.
+func downloadTransport(rt http.RoundTripper) http.RoundTripper {
+	for {
+		switch t := rt.(type) {
+		case *debugTransport:
+			rt = t.transport
+		case *recordTransport:
+			rt = t.transport
+		case *replayTransport:
+			return nil
+		default:
+			return rt
+		}
+	}
+}
+
+// DownloadExport downloads an export from the given URL, as returned by
+// [Client.Export], and saves it to filename. Data is written to filename with
+// a ".partial" suffix, which is renamed to filename once the download is
+// complete, so filename never holds an incomplete export.
+//
+// If the download fails partway, it is retried, up to 5 attempts in total. The
+// signed URLs for D1 exports support HTTP range requests, so a retry resumes
+// from the end of the partial file, with an If-Range header holding the ETag
+// or Last-Modified date of the first response, so that data of a changed
+// export is never appended; if the server ignores the range, or the export has
+// changed, the download restarts from the beginning. A partial file left by an
+// earlier call is discarded, since it may hold a different export. If progress
+// is not nil, it is called as data is written.
+//
+// Example usage:
+//
+//	err := cfd1.DownloadExport(ctx, downloadURL, "backup.sql", func(written, total int64) {
+//	    fmt.Printf("\r%d / %d bytes", written, total)
+//	})
+//
+// DownloadExport uses [http.DefaultClient]; [Client.DownloadExport] uses the
+// client's HTTP transport instead.
+func DownloadExport(ctx context.Context, url, filename string, progress DownloadProgress) error {
+	return downloadExport(ctx, http.DefaultClient, url, filename, progress)
+}
+
+// downloadExport implements DownloadExport, making requests with httpClient.
+func downloadExport(ctx context.Context, httpClient *http.Client, url, filename string, progress DownloadProgress) error {
+	// A partial file left by an earlier call may hold a different export, so
+	// only the data received by this call is resumed.
+	partial := filename + ".partial"
+	if err := os.Remove(partial); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing partial file: %w", err)
+	}
+
+	var validator string
+	var err error
+	for attempt := 1; attempt <= exportDownloadAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(time.Duration(attempt-1) * time.Second):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		var retry bool
+		if retry, err = downloadExportAttempt(ctx, httpClient, url, partial, &validator, progress); err == nil {
+			if err := os.Rename(partial, filename); err != nil {
+				return fmt.Errorf("renaming downloaded file: %w", err)
+			}
+			return nil
+		}
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// downloadExportAttempt downloads url into the file partial. If partial holds
+// data from an earlier attempt, and the response to that attempt had an ETag
+// or Last-Modified header, whose value is stored in validator, the download is
+// resumed from the end of the file if the export is unchanged. On failure, it
+// reports whether the download should be retried.
+func downloadExportAttempt(ctx context.Context, httpClient *http.Client, url, partial string, validator *string, progress DownloadProgress) (bool, error) {
+	var offset int64
+	if info, err := os.Stat(partial); err == nil && *validator != "" {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	if offset > 0 {
+		// If the export has changed, If-Range makes the server send all of it
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", *validator)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("http.Get failed: %w", err)
+		return true, fmt.Errorf("downloading export: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	flags := os.O_WRONLY | os.O_CREATE
+	total := int64(-1)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			os.Remove(partial)
+			return true, fmt.Errorf("unexpected Content-Range %q resuming from byte %d", resp.Header.Get("Content-Range"), offset)
+		}
+		flags |= os.O_APPEND
+		total = size
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file is already complete if the export has its size
+		if _, size, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && size == offset {
+			return false, nil
+		}
+		os.Remove(partial)
+		return true, fmt.Errorf("unexpected Content-Range %q resuming from byte %d", resp.Header.Get("Content-Range"), offset)
+	case resp.StatusCode == http.StatusOK:
+		flags |= os.O_TRUNC
+		offset = 0
+		total = resp.ContentLength
+		*validator = resp.Header.Get("ETag")
+		if *validator == "" || strings.HasPrefix(*validator, "W/") {
+			*validator = resp.Header.Get("Last-Modified")
+		}
+	default:
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	file, err := os.Create(filename)
+	file, err := os.OpenFile(partial, flags, 0o644)
 	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
+		return false, fmt.Errorf("creating file: %w", err)
+	}
+	var w io.Writer = file
+	if progress != nil {
+		w = &progressWriter{w: file, written: offset, total: total, progress: progress}
+		progress(offset, total)
+	}
+	_, err = io.Copy(w, resp.Body)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		return false, fmt.Errorf("writing file: %w", closeErr)
 	}
-	defer file.Close()
+	if err != nil {
+		return true, fmt.Errorf("copying data: %w", err)
+	}
+	return false, nil
+}
 
-	_, err = io.Copy(file, resp.Body)
+// parseContentRange parses the value of a Content-Range header, as in
+// "bytes 100-199/1000" or "bytes */1000", and returns the offset of the first
+// byte of the range, or -1 if there is none, the complete size, or -1 if it is
+// not known, and whether v could be parsed.
+func parseContentRange(v string) (start, size int64, ok bool) {
+	rng, sizeText, found := strings.Cut(strings.TrimPrefix(v, "bytes "), "/")
+	if !found || !strings.HasPrefix(v, "bytes ") {
+		return 0, 0, false
+	}
+	size = -1
+	if sizeText != "*" {
+		var err error
+		if size, err = strconv.ParseInt(sizeText, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	if rng == "*" {
+		return -1, size, true
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
 	if err != nil {
-		return fmt.Errorf("copying data: %w", err)
+		return 0, 0, false
 	}
+	return start, size, true
+}
+
+// progressWriter is an io.Writer that reports the bytes written to a
+// [DownloadProgress] callback.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress DownloadProgress
+}
 
-	return nil
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.progress(p.written, p.total)
+	return n, err
 }
@@ -0,0 +1,298 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrderTablesByDependency(t *testing.T) {
+	parents := map[string][]string{
+		"orders":      {"users", "products"},
+		"order_items": {"orders", "products"},
+		"products":    {"categories"},
+		"categories":  {"categories"}, // self-reference
+		"a":           {"b"},
+		"b":           {"a"}, // cycle
+	}
+
+	tests := []struct {
+		name     string
+		tables   []string
+		expected []string
+	}{
+		{"No dependencies", []string{"users", "logs"}, []string{"users", "logs"}},
+		{"Child only", []string{"order_items"}, []string{"users", "categories", "products", "orders", "order_items"}},
+		{"Child before parent", []string{"orders", "users"}, []string{"users", "categories", "products", "orders"}},
+		{"Cycle", []string{"a"}, []string{"b", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := orderTablesByDependency(tt.tables, parents)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("unexpected order: got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExportFormatSQLite(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+	})
+	_, err := client.Export(context.Background(), "db", &ExportOptions{Format: FormatSQLite})
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestExportTablesQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		tables  []string
+		queried []any
+		want    []string
+		wantErr bool
+	}{
+		{"Queried tables", nil, []any{"users", "ORDERS"}, []string{"users", "Orders"}, false},
+		{"Added to listed tables", []string{"settings"}, []any{"users"}, []string{"settings", "users"}, false},
+		{"Unknown table", nil, []any{"users", "missing"}, nil, true},
+		{"View", nil, []any{"active_users"}, nil, true},
+		{"No tables", nil, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exported []string
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/export") {
+					var body struct {
+						DumpOptions ExportOptions `json:"dump_options"`
+					}
+					json.NewDecoder(r.Body).Decode(&body)
+					exported = body.DumpOptions.Tables
+					writeAPIResult(w, map[string]any{"status": "complete", "result": map[string]string{"signed_url": "https://example.com/dump.sql"}}, nil)
+					return
+				}
+				var req rawQueryRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				if strings.Contains(req.SQL, "sqlite_master") {
+					writeAPIResult(w, []RawQueryResult{rawResult([]string{"name"}, []any{"users"}, []any{"Orders"}, []any{"settings"})}, nil)
+					return
+				}
+				if !reflect.DeepEqual(req.Params, []any{"2026-10-01"}) {
+					t.Errorf("unexpected params: %v", req.Params)
+				}
+				rs := rawResult([]string{"name"})
+				for _, name := range tt.queried {
+					rs.Results.Rows = append(rs.Results.Rows, []any{name})
+				}
+				writeAPIResult(w, []RawQueryResult{rs}, nil)
+			})
+
+			_, err := client.Export(context.Background(), "db", &ExportOptions{
+				Tables:            tt.tables,
+				TablesQuery:       "SELECT name FROM modified_tables WHERE modified >= ?",
+				TablesQueryParams: []any{"2026-10-01"},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Export() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(exported, tt.want) {
+				t.Errorf("exported tables %v, want %v", exported, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinOperationDeadline(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+	}, WithMinOperationDeadline(time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := client.Export(ctx, "db", nil); !errors.Is(err, ErrDeadlineTooShort) {
+		t.Errorf("Export: expected ErrDeadlineTooShort, got %v", err)
+	}
+	if _, err := client.Import(ctx, "db", "dump.sql"); !errors.Is(err, ErrDeadlineTooShort) {
+		t.Errorf("Import: expected ErrDeadlineTooShort, got %v", err)
+	}
+
+	// A context with a long enough deadline, or none, passes the check and
+	// fails later, when the missing file is read.
+	long, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	for _, ctx := range []context.Context{long, context.Background()} {
+		if _, err := client.Import(ctx, "db", filepath.Join(t.TempDir(), "missing.sql")); errors.Is(err, ErrDeadlineTooShort) {
+			t.Errorf("Import: unexpected ErrDeadlineTooShort")
+		}
+	}
+}
+
+func TestDownloadExportResume(t *testing.T) {
+	content := strings.Repeat("INSERT INTO t VALUES (1);\n", 1000)
+	var requests int
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		if requests == 1 {
+			// Fail partway through the first download
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write([]byte(content[:len(content)/2]))
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "export.sql", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+
+	// A partial file left by an earlier call is discarded, not resumed
+	filename := filepath.Join(t.TempDir(), "export.sql")
+	if err := os.WriteFile(filename+".partial", []byte("stale data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var lastWritten, lastTotal int64
+	err := DownloadExport(context.Background(), srv.URL, filename, func(written, total int64) {
+		lastWritten, lastTotal = written, total
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("reading file: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("downloaded content mismatch: got %d bytes, want %d", len(got), len(content))
+	}
+	if lastWritten != int64(len(content)) || lastTotal != int64(len(content)) {
+		t.Errorf("unexpected progress: %d / %d", lastWritten, lastTotal)
+	}
+	if _, err := os.Stat(filename + ".partial"); !os.IsNotExist(err) {
+		t.Errorf("partial file was not removed: %v", err)
+	}
+	if want := []string{"", fmt.Sprintf("bytes=%d-", len(content)/2)}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("requested ranges %q, want %q", ranges, want)
+	}
+}
+
+func TestDownloadExportChanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		etag     string // ETag of the export, or empty for none
+		change   bool   // whether the export changes after the first request
+		badRange bool   // whether the second response has the wrong range
+	}{
+		{"Changed", `"v1"`, true, false},
+		{"No validator", "", false, false},
+		{"Wrong range", `"v1"`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.Repeat("INSERT INTO t VALUES (1);\n", 1000)
+			var requests int
+			var ranges []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				ranges = append(ranges, r.Header.Get("Range"))
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if requests == 1 {
+					w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+					w.Write([]byte(content[:len(content)/2]))
+					if tt.change {
+						content = strings.Repeat("INSERT INTO t VALUES (2);\n", 900)
+						tt.etag = `"v2"`
+					}
+					panic(http.ErrAbortHandler)
+				}
+				if requests == 2 && tt.badRange {
+					// Ignore the requested range, but report a partial response
+					w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-9/%d", len(content)))
+					w.WriteHeader(http.StatusPartialContent)
+					w.Write([]byte(content[:10]))
+					return
+				}
+				http.ServeContent(w, r, "export.sql", time.Time{}, strings.NewReader(content))
+			}))
+			defer srv.Close()
+
+			filename := filepath.Join(t.TempDir(), "export.sql")
+			if err := DownloadExport(context.Background(), srv.URL, filename, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, _ := os.ReadFile(filename); string(got) != content {
+				t.Errorf("downloaded content mismatch: got %d bytes, want %d", len(got), len(content))
+			}
+			if tt.etag == "" && ranges[1] != "" {
+				t.Errorf("resumed without a validator: %q", ranges[1])
+			}
+		})
+	}
+}
+
+// countingTransport is an http.RoundTripper that counts the requests it makes.
+type countingTransport struct {
+	requests int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientDownloadExport(t *testing.T) {
+	const content = "CREATE TABLE t (a);\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(content))
+	}))
+	defer srv.Close()
+
+	// The download bypasses the debug logger and recorder, but not the
+	// transport beneath them
+	var logged int
+	recording := filepath.Join(t.TempDir(), "recording.json")
+	transport := &countingTransport{}
+	client := NewClient("test-account", "test-token",
+		WithHTTPClient(&http.Client{Transport: transport}), WithRequestTimeout(time.Millisecond),
+		WithRecorder(recording),
+		WithDebugLogger(debugLoggerFunc(func(string, string, []byte, []byte, int) { logged++ })))
+
+	filename := filepath.Join(t.TempDir(), "export.sql")
+	if err := client.DownloadExport(context.Background(), srv.URL, filename, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := os.ReadFile(filename); string(got) != content {
+		t.Errorf("unexpected content: %q", got)
+	}
+	if transport.requests != 1 {
+		t.Errorf("client transport made %d requests, want 1", transport.requests)
+	}
+	if logged != 0 {
+		t.Errorf("debug logger logged %d requests, want 0", logged)
+	}
+	if _, err := os.Stat(recording); !os.IsNotExist(err) {
+		t.Errorf("download was recorded: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.DownloadExport(ctx, srv.URL, filename, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
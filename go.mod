@@ -4,4 +4,4 @@ go 1.23.0
 
 require github.com/joho/godotenv v1.5.1
 
-require github.com/matryer/is v1.4.1 // indirect
+require github.com/matryer/is v1.4.1
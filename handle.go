@@ -2,7 +2,11 @@ package cfd1
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Handle represents a psuedo-connection to a single D1 database, similar to a
@@ -18,12 +22,137 @@ type Handle struct {
 	lastRowID   int
 	lastMeta    QueryMeta
 	mux         sync.RWMutex
+
+	timeout  time.Duration // per-call timeout for queries; zero for none
+	readOnly bool          // reject queries that may write
+	reads    *Handle       // handle for read-only queries; see WithReadRouting
+}
+
+// WithTimeout returns a new handle for the same database that applies timeout
+// d to each query it executes, in addition to any deadline of the context
+// passed to the query. The returned handle shares h's client but has its own
+// row counters, starting from zero; h is not modified. Imports and exports,
+// which can legitimately run for minutes, are not subject to the timeout.
+//
+// Example usage:
+//
+//	h := baseHandle.WithTimeout(5 * time.Second)
+//	rows, err := h.Query(ctx, "SELECT * FROM users") // fails after 5s
+func (h *Handle) WithTimeout(d time.Duration) *Handle {
+	derived := h.derive()
+	derived.timeout = d
+	return derived
+}
+
+// Clone returns a new handle for the same database and client, with the same
+// settings as h, including any timeout, but with its own counters, starting
+// from zero. This allows the cost of a discrete unit of work to be measured
+// in isolation, while other work shares the original handle.
+//
+// Example usage:
+//
+//	work := h.Clone()
+//	err := processOrder(ctx, work, order)
+//	log.Printf("order %d: %d rows read, %d written", order.ID, work.RowsRead(), work.RowsWritten())
+func (h *Handle) Clone() *Handle {
+	return h.derive()
+}
+
+// derive returns a new handle for the same database and client, with the same
+// settings as h but its own counters.
+func (h *Handle) derive() *Handle {
+	return &Handle{
+		client:   h.client,
+		dbID:     h.dbID,
+		timeout:  h.timeout,
+		readOnly: h.readOnly,
+		reads:    h.reads,
+	}
+}
+
+// ReadReplica returns a new read-only handle for the same database, suitable
+// for passing to code, such as reporting, that must never write. Queries
+// through the returned handle that contain a statement which may modify the
+// database are rejected with an error wrapping [ErrReadOnly] before being sent,
+// as are imports and restores. Statements are classified by their leading
+// keyword: SELECT, VALUES, EXPLAIN, read-only WITH and PRAGMA statements, and
+// transaction control statements are allowed. Like [Handle.Clone], the handle
+// has its own counters and h is not modified.
+//
+// D1 read replicas are currently only reachable through the Sessions API of the
+// Workers binding, not the REST API, so queries through the returned handle
+// are still served by the primary database. When the REST API exposes replica
+// reads, this handle will route its queries to them.
+func (h *Handle) ReadReplica() *Handle {
+	derived := h.derive()
+	derived.readOnly = true
+	return derived
+}
+
+// WithReadRouting returns a new handle for the same database that sends each
+// query containing only read-only statements to reads, and all other queries
+// to this database, so that reads can be split from writes without annotating
+// each call. Statements are classified by their leading keyword, as described
+// for [Handle.ReadReplica]: SELECT, VALUES, EXPLAIN, and read-only WITH and
+// PRAGMA statements are reads, and everything else is a write. A query with
+// several statements is sent to reads only if none of them is a write, so a
+// batch containing a write always goes to this database. Transaction control
+// statements are ignored.
+//
+// reads is typically a handle returned by [Handle.ReadReplica], or a handle for
+// another database that the application keeps as a read copy. Queries sent to
+// reads are subject to its settings, such as its timeout, and are counted by
+// both handles. Like [Handle.Clone], the returned handle has its own counters,
+// and h is not modified.
+//
+// Example usage:
+//
+//	rw := h.WithReadRouting(h.ReadReplica())
+//	users, err := rw.Query(ctx, "SELECT * FROM users")        // sent to the replica handle
+//	err = rw.Execute(ctx, "UPDATE users SET active = 0")       // sent to h's database
+func (h *Handle) WithReadRouting(reads *Handle) *Handle {
+	derived := h.derive()
+	derived.reads = reads
+	return derived
+}
+
+// reader returns the handle set with WithReadRouting if sql should be sent to
+// it, and otherwise nil.
+func (h *Handle) reader(sql string) *Handle {
+	if h.reads == nil || h.reads == h || !isReadOnlyQuery(sql) {
+		return nil
+	}
+	return h.reads
+}
+
+// checkReadOnly returns an error wrapping ErrReadOnly if the handle is
+// read-only and sql contains a statement that may modify the database.
+func (h *Handle) checkReadOnly(sql string) error {
+	if !h.readOnly {
+		return nil
+	}
+	for i, stmt := range splitStatements(tokenizeSQL(sql)) {
+		if isWriteStatement(stmt) && !isTransactionStatement(stmt) {
+			return fmt.Errorf("%w: statement %d begins with %s", ErrReadOnly, i+1, strings.ToUpper(stmt[0].text))
+		}
+	}
+	return nil
+}
+
+// context returns ctx with the handle's timeout applied, if it has one.
+func (h *Handle) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, h.timeout)
 }
 
-// Ping sends a ping request to the database to check if it is reachable.
+// Ping sends a ping request to the database to check if it is reachable. Errors
+// are reported as by [Client.Ping].
 func (h *Handle) Ping(ctx context.Context) error {
-	_, err := h.Query(ctx, "SELECT 1")
-	return err
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+	return h.client.Ping(ctx, h.dbID)
 }
 
 // Query executes a SQL query on this database and returns the results. The
@@ -31,19 +160,114 @@ func (h *Handle) Ping(ctx context.Context) error {
 // executed as a batch, and be up to 100KB. A maximum of 100 placeholder
 // parameters can be used.
 func (h *Handle) Query(ctx context.Context, sql string, params ...any) ([]map[string]any, error) {
+	result, err := h.query(ctx, sql, params...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// QueryFull executes a SQL query on this database, like [Handle.Query], and
+// returns its complete result, including the column names and the [QueryMeta]
+// describing its execution, such as the number of rows read and its duration.
+// Unlike [Handle.LastMeta], the returned metadata always belongs to this call,
+// even when the handle is used concurrently.
+func (h *Handle) QueryFull(ctx context.Context, sql string, params ...any) (*QueryResult, error) {
+	return h.query(ctx, sql, params...)
+}
+
+// QueryWithTimeout executes a SQL query on this database, like [Handle.Query],
+// with its HTTP request limited to timeout in place of the client's request
+// timeout, as described for [WithHTTPTimeout]. Any timeout of the handle, set
+// with [Handle.WithTimeout], still applies.
+func (h *Handle) QueryWithTimeout(ctx context.Context, timeout time.Duration, sql string, params ...any) ([]map[string]any, error) {
+	return h.Query(WithHTTPTimeout(ctx, timeout), sql, params...)
+}
+
+// query executes a SQL query on this database, updates the handle's counters,
+// and returns the complete result.
+func (h *Handle) query(ctx context.Context, sql string, params ...any) (*QueryResult, error) {
+	if err := h.checkReadOnly(sql); err != nil {
+		return nil, err
+	}
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+	if r := h.reader(sql); r != nil {
+		result, err := r.query(ctx, sql, params...)
+		if err == nil {
+			h.recordMeta(result.Meta)
+		}
+		return result, err
+	}
 	result, err := h.client.Query(ctx, h.dbID, sql, params...)
 	if err != nil {
+		return nil, h.queryError(err)
+	}
+
+	h.recordMeta(result.Meta)
+	return result, nil
+}
+
+// rawQuery executes a SQL query on this database using the raw API, updates the
+// handle's counters, and returns the results.
+func (h *Handle) rawQuery(ctx context.Context, sql string, params ...any) ([]RawQueryResult, error) {
+	if err := h.checkReadOnly(sql); err != nil {
 		return nil, err
 	}
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+	if r := h.reader(sql); r != nil {
+		result, err := r.rawQuery(ctx, sql, params...)
+		if err == nil {
+			h.recordMeta(resultMetas(result)...)
+		}
+		return result, err
+	}
+	result, err := h.client.RawQuery(ctx, h.dbID, sql, params...)
+	if err != nil {
+		return nil, h.queryError(err)
+	}
+
+	h.recordMeta(resultMetas(result)...)
+	return result, nil
+}
+
+// resultMetas returns the metadata of each of results.
+func resultMetas(results []RawQueryResult) []QueryMeta {
+	metas := make([]QueryMeta, len(results))
+	for i := range results {
+		metas[i] = results[i].Meta
+	}
+	return metas
+}
+
+// queryError wraps an error from a query on this database in a
+// [DatabaseFullError] if the database is full, and returns other errors
+// unchanged.
+func (h *Handle) queryError(err error) error {
+	if !errors.Is(err, ErrDatabaseFull) {
+		return err
+	}
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+	return &DatabaseFullError{SizeBytes: h.lastMeta.SizeAfter, Err: err}
+}
+
+// recordMeta adds the rows read and written in metas to the handle's counters,
+// and remembers the last of them as the handle's most recent metadata.
+func (h *Handle) recordMeta(metas ...QueryMeta) {
+	if len(metas) == 0 {
+		return
+	}
 
 	h.mux.Lock()
 	defer h.mux.Unlock()
-	h.rowsRead += result.Meta.RowsRead
-	h.rowsWritten += result.Meta.RowsWritten
-	h.lastRowID = result.Meta.LastRowID
-	h.lastMeta = result.Meta
-
-	return result.Results, nil
+	for _, meta := range metas {
+		h.rowsRead += meta.RowsRead
+		h.rowsWritten += meta.RowsWritten
+	}
+	h.lastRowID = metas[len(metas)-1].LastRowID
+	h.lastMeta = metas[len(metas)-1]
 }
 
 // Execute executes a SQL query on this database that has no results. The query
@@ -55,19 +279,164 @@ func (h *Handle) Execute(ctx context.Context, sql string, params ...any) error {
 	return err
 }
 
+// Validate checks that sql, which may contain multiple semicolon-separated
+// statements, is valid for this database without executing it. Each statement
+// is prefixed with EXPLAIN QUERY PLAN, which makes SQLite compile it, checking
+// both its syntax and the tables and columns it refers to, without running it
+// or causing any side effects. If a statement is invalid, the [SQLiteError]
+// describing the problem is returned; its Query field holds the EXPLAIN form of
+// the statements.
+//
+// Because nothing is executed, a statement that refers to an object created by
+// an earlier statement in sql fails validation unless the object already
+// exists. Placeholders are accepted without needing parameters. Validate does
+// not update the handle's counters.
+func (h *Handle) Validate(ctx context.Context, sql string) error {
+	var explained []string
+	for _, stmt := range splitStatements(tokenizeSQL(sql)) {
+		last := stmt[len(stmt)-1]
+		text := sql[stmt[0].pos : last.pos+len(last.text)]
+		if !stmt[0].is("EXPLAIN") {
+			text = "EXPLAIN QUERY PLAN " + text
+		}
+		explained = append(explained, text)
+	}
+	if len(explained) == 0 {
+		return nil
+	}
+
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+	_, err := h.client.RawQuery(ctx, h.dbID, strings.Join(explained, ";\n"))
+	return err
+}
+
+// ExecuteWithMeta executes a SQL query on this database that has no results,
+// like [Handle.Execute], and returns the [QueryMeta] describing its execution.
+// This includes the number of changes, the last inserted row ID, the duration,
+// and the size of the database afterwards. Unlike [Handle.LastMeta], the
+// returned metadata always belongs to this call, even when the handle is used
+// concurrently.
+func (h *Handle) ExecuteWithMeta(ctx context.Context, sql string, params ...any) (QueryMeta, error) {
+	result, err := h.query(ctx, sql, params...)
+	if err != nil {
+		return QueryMeta{}, err
+	}
+	return result.Meta, nil
+}
+
 // QueryRow executes a SQL query on this database and returns a single row of
 // results as a Row object, suitable for calling Scan. If the query returns
 // multiple rows, only the first row is reachable.
 func (h *Handle) QueryRow(ctx context.Context, sql string, params ...any) *Row {
-	result, err := h.client.RawQuery(ctx, h.dbID, sql, params...)
-	return newRow(&result[0], err)
+	result, err := h.rawQuery(ctx, sql, params...)
+	if err != nil || len(result) == 0 {
+		return newRow(nil, false, err)
+	}
+	return newRow(&result[0], h.client.strictScan, nil)
+}
+
+// QueryRowScan executes a SQL query on this database and scans the first row of
+// results into dest. Query parameters and scan destinations are passed
+// separately, as params and dest respectively. If the query returns no rows,
+// sql.ErrNoRows is returned.
+//
+// Example usage:
+//
+//	var name string
+//	var age int
+//	err := h.QueryRowScan(ctx, "SELECT name, age FROM users WHERE id = ?",
+//	    []any{42}, &name, &age)
+func (h *Handle) QueryRowScan(ctx context.Context, sql string, params []any, dest ...any) error {
+	return h.QueryRow(ctx, sql, params...).Scan(dest...)
 }
 
 // QueryRows executes a SQL query on this database and returns a Rows object
 // that can iterate the resultsets and rows.
 func (h *Handle) QueryRows(ctx context.Context, sql string, params ...any) *Rows {
-	result, err := h.client.RawQuery(ctx, h.dbID, sql, params...)
-	return newRows(result, err)
+	result, err := h.rawQuery(ctx, sql, params...)
+	return newRows(result, h.client.strictScan, err)
+}
+
+// QueryChan executes a SQL query on this database and sends the rows of its
+// first result set, in order, on the returned row channel, for processing in a
+// pipeline. The row channel is closed after the last row is sent, or when the
+// query fails or ctx is done, in which case the error is sent on the returned
+// error channel; otherwise it is closed without a value. The row channel is
+// unbuffered, so rows are produced only as fast as they are received. The
+// caller must receive from it until it is closed, or cancel ctx, to release
+// the goroutine sending the rows.
+//
+// D1 returns the complete result of a query in one response, so this is a
+// convenience rather than true streaming: every row is held in memory until
+// it is sent. To limit memory use for large tables, page through them with
+// LIMIT and OFFSET, or by key, making a query for each page.
+//
+// Example usage:
+//
+//	rows, errc := h.QueryChan(ctx, "SELECT id, payload FROM events")
+//	for row := range rows {
+//	    process(row)
+//	}
+//	if err := <-errc; err != nil {
+//	    // handle error
+//	}
+func (h *Handle) QueryChan(ctx context.Context, sql string, params ...any) (<-chan []any, <-chan error) {
+	rows := make(chan []any)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(rows)
+		defer close(errc)
+		result, err := h.rawQuery(ctx, sql, params...)
+		if err != nil {
+			errc <- err
+			return
+		}
+		if len(result) == 0 {
+			return
+		}
+		for _, row := range result[0].Results.Rows {
+			// A select with both cases ready chooses at random, so the context
+			// is checked first to stop at the next row once it is done.
+			if err := ctx.Err(); err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case rows <- row:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return rows, errc
+}
+
+// QueryRawBytes executes a SQL query on this database and returns the body of
+// the API's response as it was received, without decoding it, for debugging
+// queries whose results are not what was expected. Parameters are converted as
+// for other queries, but the query is sent as given: the client's query
+// features, such as row limits, implicit transactions, and column
+// transformers, are not applied, and the handle's counters are not updated.
+//
+// If the API returns an error, it is returned along with the response body.
+//
+// Example usage:
+//
+//	body, err := h.QueryRawBytes(ctx, "SELECT * FROM events WHERE id = ?", 42)
+//	fmt.Printf("%s\n", body)
+func (h *Handle) QueryRawBytes(ctx context.Context, sql string, params ...any) ([]byte, error) {
+	if err := h.checkReadOnly(sql); err != nil {
+		return nil, err
+	}
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+	body, err := h.client.queryRawBytes(ctx, h.dbID, sql, params...)
+	if err != nil {
+		return body, h.queryError(err)
+	}
+	return body, nil
 }
 
 // Export initiates an export (SQL dump) on this database. It accepts an
@@ -85,6 +454,9 @@ func (h *Handle) Export(ctx context.Context, opts *ExportOptions) (string, error
 // import is complete. The database will be unavailable for other queries for
 // the duration of the import.
 func (h *Handle) Import(ctx context.Context, sqlFilePath string) (*ImportResult, error) {
+	if h.readOnly {
+		return nil, fmt.Errorf("importing: %w", ErrReadOnly)
+	}
 	result, err := h.client.Import(ctx, h.dbID, sqlFilePath)
 	if err != nil {
 		return nil, err
@@ -114,6 +486,9 @@ func (h *Handle) LastRowID() int {
 }
 
 // LastMeta returns the [QueryMeta] for the last query executed by this handle.
+// When the handle is used concurrently, this may belong to another goroutine's
+// query; use [Handle.QueryFull] or [Handle.ExecuteWithMeta] to get the metadata
+// of a specific call.
 func (h *Handle) LastMeta() QueryMeta {
 	h.mux.RLock()
 	defer h.mux.RUnlock()
@@ -123,21 +498,92 @@ func (h *Handle) LastMeta() QueryMeta {
 // GetDetails returns the current DatabaseDetails describing this database,
 // including the number of tables and size on disk.
 func (h *Handle) GetDetails(ctx context.Context) (*DatabaseDetails, error) {
+	ctx, cancel := h.context(ctx)
+	defer cancel()
 	return h.client.GetDatabase(ctx, h.dbID)
 }
 
+// SQLiteVersion returns the version of the SQLite engine that executes queries
+// on this database, such as "3.45.1", by running SELECT sqlite_version(). This
+// determines which SQL functions and syntax are available. It is unrelated to
+// the Version field of [DatabaseDetails], which identifies the version of D1's
+// storage backend.
+func (h *Handle) SQLiteVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := h.QueryRow(ctx, "SELECT sqlite_version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("getting SQLite version: %w", err)
+	}
+	return version, nil
+}
+
+// SizeWarning reports whether this database has grown to at least the given
+// fraction of [MaxDatabaseSize], such as 0.9 for 90%, along with its current
+// size in bytes. The size is retrieved with [Handle.GetDetails].
+func (h *Handle) SizeWarning(ctx context.Context, fraction float64) (bool, int, error) {
+	details, err := h.GetDetails(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	return float64(details.FileSize) >= fraction*MaxDatabaseSize, details.FileSize, nil
+}
+
 // RowsRead returns the total number of rows read during the lifetime of this
-// handle.
+// handle. Every query made through the handle is counted, including those of
+// [Handle.QueryRow], [Handle.QueryRows], and the helpers built on them.
 func (h *Handle) RowsRead() int {
 	h.mux.RLock()
-	defer h.mux.Unlock()
+	defer h.mux.RUnlock()
 	return h.rowsRead
 }
 
 // RowsWritten returns the total number of rows written during the lifetime of
-// this handle.
+// this handle, counting every query as described for [Handle.RowsRead].
 func (h *Handle) RowsWritten() int {
 	h.mux.RLock()
-	defer h.mux.Unlock()
+	defer h.mux.RUnlock()
 	return h.rowsWritten
 }
+
+// Truncate deletes all rows from table, and returns the number of rows deleted.
+// SQLite has no TRUNCATE statement; Truncate issues DELETE FROM, which SQLite
+// optimizes when there is no WHERE clause. The table must exist, as a table
+// rather than a view, which is checked first to guard against typos; internal
+// tables, whose names begin with "sqlite_" or "_cf_", cannot be truncated.
+//
+// Deleting all rows does not reset the counter of an AUTOINCREMENT column, so
+// new rows continue to receive rowids above those previously used. If
+// resetAutoincrement is true, the table's entry in sqlite_sequence is also
+// deleted, in the same request as the rows, so that rowids start again from 1.
+func (h *Handle) Truncate(ctx context.Context, table string, resetAutoincrement bool) (int64, error) {
+	lower := strings.ToLower(table)
+	if strings.HasPrefix(lower, "sqlite_") || strings.HasPrefix(lower, "_cf_") {
+		return 0, fmt.Errorf("truncating %s: cannot truncate internal table", table)
+	}
+
+	var name string
+	var hasSequence int64 // EXISTS returns an integer, which a strict scan does not convert to bool
+	err := h.QueryRowScan(ctx, "SELECT (SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?1 COLLATE NOCASE), "+
+		"EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_sequence')",
+		[]any{table}, &name, &hasSequence)
+	if err != nil {
+		return 0, fmt.Errorf("truncating %s: %w", table, err)
+	}
+	if name == "" {
+		return 0, fmt.Errorf("truncating %s: no such table", table)
+	}
+
+	sql := "DELETE FROM " + QuoteIdentifier(name)
+	var params []any
+	if resetAutoincrement && hasSequence != 0 {
+		sql += "; DELETE FROM sqlite_sequence WHERE name = ?"
+		params = append(params, name)
+	}
+	result, err := h.rawQuery(ctx, sql, params...)
+	if err != nil {
+		return 0, fmt.Errorf("truncating %s: %w", table, err)
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return int64(result[0].Meta.Changes), nil
+}
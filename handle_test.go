@@ -0,0 +1,470 @@
+package cfd1
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHandleWithTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+			writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1})}, nil)
+		}
+	})
+	base, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	h := base.WithTimeout(20 * time.Millisecond)
+
+	if base.timeout != 0 {
+		t.Errorf("base handle was modified")
+	}
+	err := h.QueryRow(context.Background(), "SELECT 1").Err()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if err := base.QueryRow(context.Background(), "SELECT 1").Err(); err != nil {
+		t.Errorf("unexpected error from base handle: %v", err)
+	}
+}
+
+func TestHandleQueryWithTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+			writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1})}, nil)
+		}
+	}, WithRequestTimeout(20*time.Millisecond))
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	if _, err := h.Query(context.Background(), "SELECT 1"); err == nil {
+		t.Error("expected the client's request timeout to apply")
+	}
+	if _, err := h.QueryWithTimeout(context.Background(), time.Second, "SELECT 1"); err != nil {
+		t.Errorf("unexpected error with a longer timeout: %v", err)
+	}
+	if _, err := h.QueryWithTimeout(context.Background(), 0, "SELECT 1"); err != nil {
+		t.Errorf("unexpected error with no timeout: %v", err)
+	}
+	_, err := h.QueryWithTimeout(context.Background(), 10*time.Millisecond, "SELECT 1")
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected ErrCanceled wrapping context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestHandleQueryRowScan(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Params[0] == 0.0 {
+			writeAPIResult(w, []RawQueryResult{rawResult([]string{"name", "age"})}, nil)
+			return
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"name", "age"}, []any{"alice", 30}, []any{"bob", 40})}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	// The params are bound to the query and the first row is scanned into dest
+	var name string
+	var age int
+	if err := h.QueryRowScan(context.Background(), "SELECT name, age FROM users WHERE id > ?", []any{1}, &name, &age); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "alice" || age != 30 {
+		t.Errorf("got (%q, %d), want (\"alice\", 30)", name, age)
+	}
+
+	err := h.QueryRowScan(context.Background(), "SELECT name, age FROM users WHERE id > ?", []any{0}, &name, &age)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestHandleQueryFull(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		n := int(req.Params[0].(float64))
+		result := rawResult([]string{"n"}, []any{n})
+		result.Meta = QueryMeta{RowsRead: n, Duration: float64(n) / 10}
+		writeAPIResult(w, []RawQueryResult{result}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	// Concurrent calls each receive the metadata of their own query
+	var wg sync.WaitGroup
+	for n := 1; n <= 8; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := h.QueryFull(context.Background(), "SELECT ? AS n", n)
+			if err != nil {
+				t.Errorf("query %d: unexpected error: %v", n, err)
+				return
+			}
+			if result.Meta.RowsRead != n || result.Meta.Duration != float64(n)/10 {
+				t.Errorf("query %d: got meta %+v", n, result.Meta)
+			}
+			if len(result.Columns) != 1 || result.Columns[0] != "n" || result.Results[0]["n"] != int64(n) {
+				t.Errorf("query %d: got columns %v and results %v", n, result.Columns, result.Results)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHandleCountersScanned(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		result := rawResult([]string{"x"}, []any{1}, []any{2})
+		result.Meta = QueryMeta{RowsRead: 2, LastRowID: 7}
+		writeAPIResult(w, []RawQueryResult{result}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	// Queries whose rows are scanned count toward the handle's counters and
+	// metadata, like those of Query
+	var x int
+	if err := h.QueryRow(context.Background(), "SELECT x FROM t").Scan(&x); err != nil {
+		t.Fatalf("QueryRow: unexpected error: %v", err)
+	}
+	if err := h.QueryRows(context.Background(), "SELECT x FROM t").Err(); err != nil {
+		t.Fatalf("QueryRows: unexpected error: %v", err)
+	}
+	if h.RowsRead() != 4 || h.LastMeta().RowsRead != 2 || h.LastRowID() != 7 {
+		t.Errorf("got %d rows read, last meta %+v, last row ID %d; want 4, 2 rows read, 7",
+			h.RowsRead(), h.LastMeta(), h.LastRowID())
+	}
+}
+
+func TestHandleClone(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1}, []any{2})}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	if err := h.QueryRow(context.Background(), "SELECT x FROM t").Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clone := h.Clone()
+	if got := clone.RowsRead(); got != 0 {
+		t.Errorf("clone RowsRead: got %d, want 0", got)
+	}
+	if err := clone.QueryRow(context.Background(), "SELECT x FROM t").Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := clone.RowsRead(); got != 2 {
+		t.Errorf("clone RowsRead: got %d, want 2", got)
+	}
+	if got := h.RowsRead(); got != 2 {
+		t.Errorf("original RowsRead: got %d, want 2", got)
+	}
+}
+
+func TestHandleValidate(t *testing.T) {
+	var req rawQueryRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.SQL, "missing") {
+			writeAPIError(w, http.StatusBadRequest, 7500, "no such table: missing: SQLITE_ERROR")
+			return
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"detail"})}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	if err := h.Validate(context.Background(), "INSERT INTO users (name) VALUES (?); EXPLAIN SELECT 1;"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	want := "EXPLAIN QUERY PLAN INSERT INTO users (name) VALUES (?);\nEXPLAIN SELECT 1"
+	if req.SQL != want {
+		t.Errorf("unexpected SQL: got %q, want %q", req.SQL, want)
+	}
+
+	err := h.Validate(context.Background(), "DELETE FROM missing")
+	if !errors.Is(err, ErrSQLite) {
+		t.Errorf("expected ErrSQLite, got %v", err)
+	}
+	if h.RowsRead() != 0 {
+		t.Errorf("Validate updated the handle's counters")
+	}
+}
+
+func TestHandleReadReplica(t *testing.T) {
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1})}, nil)
+	})
+	base, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	h := base.ReadReplica()
+
+	tests := []struct {
+		sql     string
+		wantErr bool
+	}{
+		{"SELECT * FROM t", false},
+		{"WITH c AS (SELECT 1) SELECT * FROM c", false},
+		{"PRAGMA table_info(t)", false},
+		{"BEGIN; SELECT 1; COMMIT", false},
+		{"INSERT INTO t VALUES (1)", true},
+		{"SELECT 1; DELETE FROM t", true},
+		{"PRAGMA foreign_keys = ON", true},
+		{"CREATE TABLE t2 (a)", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			requests = 0
+			_, err := h.rawQuery(context.Background(), tt.sql)
+			if tt.wantErr {
+				if !errors.Is(err, ErrReadOnly) {
+					t.Errorf("expected ErrReadOnly, got %v", err)
+				}
+				if requests != 0 {
+					t.Errorf("rejected query was sent")
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	if base.readOnly {
+		t.Errorf("base handle was modified")
+	}
+	if _, err := base.rawQuery(context.Background(), "DELETE FROM t"); err != nil {
+		t.Errorf("unexpected error from base handle: %v", err)
+	}
+	if _, err := h.RestoreToBookmark(context.Background(), "bookmark"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly from RestoreToBookmark, got %v", err)
+	}
+}
+
+func TestHandleWithReadRouting(t *testing.T) {
+	const primaryID = "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f"
+	const copyID = "c0c0c0c0-4555-4777-b222-1a2b3c4d5e6f"
+	var path string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		rs := rawResult([]string{"x"}, []any{1})
+		rs.Meta.RowsRead = 1
+		writeAPIResult(w, []RawQueryResult{rs}, nil)
+	})
+	primary, _ := client.GetHandle(context.Background(), primaryID)
+	readCopy, _ := client.GetHandle(context.Background(), copyID)
+	rw := primary.WithReadRouting(readCopy)
+
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT * FROM t", copyID},
+		{"EXPLAIN SELECT 1", copyID},
+		{"PRAGMA table_info(t)", copyID},
+		{"BEGIN; SELECT 1; SELECT 2; COMMIT", copyID},
+		{"INSERT INTO t VALUES (1)", primaryID},
+		{"SELECT 1; DELETE FROM t", primaryID},
+		{"PRAGMA foreign_keys = ON", primaryID},
+		{"-- nothing", primaryID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			for name, query := range map[string]func() error{
+				"Query":    func() error { _, err := rw.Query(context.Background(), tt.sql); return err },
+				"rawQuery": func() error { _, err := rw.rawQuery(context.Background(), tt.sql); return err },
+			} {
+				if err := query(); err != nil {
+					t.Fatalf("%s: unexpected error: %v", name, err)
+				}
+				if want := "/accounts/test-account/d1/database/" + tt.want + "/raw"; path != want {
+					t.Errorf("%s: sent to %s, want %s", name, path, want)
+				}
+			}
+		})
+	}
+
+	// Routed reads, 4 queries run twice each, are counted by both handles
+	if rw.RowsRead() != 2*len(tests) || readCopy.RowsRead() != 8 || primary.RowsRead() != 0 {
+		t.Errorf("unexpected counters: %d, %d, %d", rw.RowsRead(), readCopy.RowsRead(), primary.RowsRead())
+	}
+}
+
+func TestHandleTruncate(t *testing.T) {
+	var requests []rawQueryRequest
+	var tableName any
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		if strings.HasPrefix(req.SQL, "SELECT") {
+			writeAPIResult(w, []RawQueryResult{rawResult([]string{"name", "seq"}, []any{tableName, 1.0})}, nil)
+			return
+		}
+		var rs RawQueryResult
+		rs.Meta.Changes = 3
+		writeAPIResult(w, []RawQueryResult{rs, rawResult(nil)}, nil)
+	}
+	client := newTestClient(t, handler)
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	tests := []struct {
+		name    string
+		table   string
+		exists  any
+		reset   bool
+		sql     string
+		wantErr bool
+	}{
+		{"Truncate", "users", "Users", false, `DELETE FROM "Users"`, false},
+		{"Reset autoincrement", "users", "Users", true, `DELETE FROM "Users"; DELETE FROM sqlite_sequence WHERE name = ?`, false},
+		{"Missing table", "usres", nil, false, "", true},
+		{"Internal table", "sqlite_sequence", nil, false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, tableName = nil, tt.exists
+			n, err := h.Truncate(context.Background(), tt.table, tt.reset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Truncate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				for _, req := range requests {
+					if strings.HasPrefix(req.SQL, "DELETE") {
+						t.Errorf("unexpected DELETE: %s", req.SQL)
+					}
+				}
+				return
+			}
+			if n != 3 {
+				t.Errorf("Truncate() = %d, want 3", n)
+			}
+			if len(requests) != 2 || requests[1].SQL != tt.sql {
+				t.Errorf("unexpected requests: %+v", requests)
+			}
+		})
+	}
+
+	t.Run("Strict scan", func(t *testing.T) {
+		requests, tableName = nil, "users"
+		client := newTestClient(t, handler, WithStrictScan())
+		h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+		if _, err := h.Truncate(context.Background(), "users", true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(requests) != 2 || !strings.Contains(requests[1].SQL, "sqlite_sequence") {
+			t.Errorf("unexpected requests: %+v", requests)
+		}
+	})
+}
+
+func TestHandleQueryRawBytes(t *testing.T) {
+	const response = `{"result":[{"results":{"columns":["x"],"rows":[[1]]},"success":true,"meta":{}}],"success":true,"errors":[]}`
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.SQL == "SELECT bad" {
+			writeAPIError(w, http.StatusBadRequest, 7500, "no such column: bad: SQLITE_ERROR")
+			return
+		}
+		if req.SQL != "SELECT x FROM t WHERE ok = ?" || len(req.Params) != 1 || req.Params[0] != 1.0 {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, response)
+	}, WithImplicitTransactions(), WithMaxResultRows(10))
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	// The query is sent as given, without a LIMIT clause, and a bool
+	// parameter is converted as usual.
+	body, err := h.QueryRawBytes(context.Background(), "SELECT x FROM t WHERE ok = ?", true)
+	if err != nil || string(body) != response {
+		t.Errorf("got (%s, %v), want %s", body, err, response)
+	}
+
+	body, err = h.QueryRawBytes(context.Background(), "SELECT bad")
+	if !errors.Is(err, ErrSQLite) || !strings.Contains(string(body), "no such column") {
+		t.Errorf("got (%s, %v), want SQLite error with body", body, err)
+	}
+
+	if _, err := h.ReadReplica().QueryRawBytes(context.Background(), "DELETE FROM t"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+}
+
+func TestHandleSQLiteVersion(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.SQL != "SELECT sqlite_version()" {
+			t.Errorf("unexpected SQL: %q", req.SQL)
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"sqlite_version()"}, []any{"3.45.1"})}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	version, err := h.SQLiteVersion(context.Background())
+	if err != nil || version != "3.45.1" {
+		t.Errorf("got (%q, %v), want 3.45.1", version, err)
+	}
+}
+
+func TestHandleQueryChan(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.SQL, "missing") {
+			writeAPIError(w, http.StatusBadRequest, 7500, "no such table: missing: SQLITE_ERROR")
+			return
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1}, []any{2}, []any{3})}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	rows, errc := h.QueryChan(context.Background(), "SELECT x FROM t")
+	var got []int64
+	for row := range rows {
+		got = append(got, row[0].(int64))
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(got) != 3 || got[2] != 3 {
+		t.Errorf("unexpected rows: %v", got)
+	}
+
+	rows, errc = h.QueryChan(context.Background(), "SELECT x FROM missing")
+	if _, ok := <-rows; ok {
+		t.Error("expected no rows from a failed query")
+	}
+	var sqliteErr *SQLiteError
+	if err := <-errc; !errors.As(err, &sqliteErr) {
+		t.Errorf("expected SQLiteError, got %v", err)
+	}
+
+	// Canceling stops the rows, and reports the context's error. At most the
+	// row whose send was already waiting is received after the cancellation.
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, errc = h.QueryChan(ctx, "SELECT x FROM t")
+	<-rows
+	cancel()
+	var after int
+	for range rows {
+		after++
+	}
+	if after > 1 {
+		t.Errorf("received %d rows after canceling, want at most 1", after)
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package cfd1
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// QueryIn executes a query with an IN list built from inValues, and appends the
+// resulting rows to dest. The query is formed as sqlPrefix, followed by a
+// parenthesized list with one placeholder per value, followed by sqlSuffix. If
+// T is a struct, columns are matched to fields as with [Row.ScanStruct];
+// otherwise, the first column of each row is scanned into a T.
+//
+// D1 allows at most [MaxQueryParams] placeholders per query. If inValues has
+// more elements than that, the list is split into chunks, one query is
+// executed per chunk, and the results are appended to dest in chunk order.
+// Clauses in sqlSuffix, such as ORDER BY or LIMIT, therefore apply to each
+// chunk separately. If inValues is empty, no query is executed.
+//
+// Example usage:
+//
+//	var users []User
+//	err := cfd1.QueryIn(ctx, h, &users, "SELECT * FROM users WHERE id IN", ids, "")
+func QueryIn[T any](ctx context.Context, h *Handle, dest *[]T, sqlPrefix string, inValues []any, sqlSuffix string) error {
+	limit := h.client.paramLimit()
+	for start := 0; start < len(inValues); start += limit {
+		chunk := inValues[start:min(start+limit, len(inValues))]
+		placeholders := strings.Repeat("?, ", len(chunk)-1) + "?"
+		sql := sqlPrefix + " (" + placeholders + ") " + sqlSuffix
+
+		result, err := h.rawQuery(ctx, sql, chunk...)
+		if err != nil {
+			return err
+		}
+		for i := range result {
+			rs := &result[i].Results
+			if err := appendRows(rs.Columns, rs.Rows, reflect.ValueOf(dest), h.client.strictScan); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// QueryScalar executes a query and returns the value of the first column of the
+// first row, converted to T as with [Row.Scan]. It returns sql.ErrNoRows if the
+// query returns no rows, and an error if the value cannot be converted to T.
+//
+// Example usage:
+//
+//	count, err := cfd1.QueryScalar[int](ctx, h, "SELECT COUNT(*) FROM users WHERE active = ?", true)
+func QueryScalar[T any](ctx context.Context, h *Handle, sql string, params ...any) (T, error) {
+	var v T
+	if err := h.QueryRow(ctx, sql, params...).Scan(&v); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
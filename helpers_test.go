@@ -0,0 +1,110 @@
+package cfd1
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// rawQueryRequest is the request body sent to the query and raw endpoints.
+type rawQueryRequest struct {
+	SQL    string `json:"sql"`
+	Params []any  `json:"params"`
+}
+
+// rawResult returns a single raw result set with the given columns and rows.
+func rawResult(cols []string, rows ...[]any) RawQueryResult {
+	var rs RawQueryResult
+	rs.Results.Columns = cols
+	rs.Results.Rows = rows
+	if rs.Results.Rows == nil {
+		rs.Results.Rows = [][]any{}
+	}
+	rs.Meta.RowsRead = len(rows)
+	rs.Success = true
+	return rs
+}
+
+func TestQueryIn(t *testing.T) {
+	var numQueries int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		numQueries++
+		if len(req.Params) > MaxQueryParams {
+			t.Errorf("too many params in one query: %d", len(req.Params))
+		}
+		var rows [][]any
+		for _, p := range req.Params {
+			rows = append(rows, []any{p, "user"})
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"id", "name"}, rows...)}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	ids := make([]any, 250)
+	for i := range ids {
+		ids[i] = i
+	}
+
+	t.Run("Scalars", func(t *testing.T) {
+		numQueries = 0
+		var got []int
+		err := QueryIn(context.Background(), h, &got, "SELECT id, name FROM users WHERE id IN", ids, "ORDER BY id")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if numQueries != 3 {
+			t.Errorf("unexpected number of queries: got %d, want 3", numQueries)
+		}
+		if len(got) != len(ids) {
+			t.Fatalf("unexpected count: got %d, want %d", len(got), len(ids))
+		}
+		for i, id := range got {
+			if id != i {
+				t.Fatalf("unexpected value at %d: got %d", i, id)
+			}
+		}
+	})
+
+	t.Run("Structs", func(t *testing.T) {
+		type user struct {
+			ID   int    `db:"id"`
+			Name string `db:"name"`
+		}
+		var got []user
+		err := QueryIn(context.Background(), h, &got, "SELECT id, name FROM users WHERE id IN", ids[:5], "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 5 || got[4].ID != 4 || got[4].Name != "user" {
+			t.Errorf("unexpected result: %+v", got)
+		}
+	})
+}
+
+func TestQueryScalar(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.SQL == "SELECT COUNT(*) FROM users" {
+			writeAPIResult(w, []RawQueryResult{rawResult([]string{"COUNT(*)"}, []any{42.0})}, nil)
+			return
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"value"})}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	count, err := QueryScalar[int](context.Background(), h, "SELECT COUNT(*) FROM users")
+	if err != nil || count != 42 {
+		t.Errorf("got (%d, %v), want (42, nil)", count, err)
+	}
+
+	value, err := QueryScalar[string](context.Background(), h, "SELECT value FROM config WHERE key = ?", "missing")
+	if !errors.Is(err, sql.ErrNoRows) || value != "" {
+		t.Errorf("got (%q, %v), want (\"\", sql.ErrNoRows)", value, err)
+	}
+}
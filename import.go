@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -50,6 +51,13 @@ type ImportResult struct {
 // an in-progress import on the API server; a context cancellation will only
 // stop the polling loop.
 //
+// The file is uploaded uncompressed. The D1 import API identifies the upload by
+// the MD5 hash of the SQL file and ingests the uploaded object as plain SQL; it
+// does not document support for compressed uploads or a Content-Encoding
+// header, so compressing the file in transit is not possible. The hash does
+// allow an unchanged file to be imported again, for example after a failed
+// import, without being uploaded a second time.
+//
 // Example usage:
 //
 //	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
@@ -61,9 +69,17 @@ type ImportResult struct {
 //	}
 //	fmt.Printf("Database import complete. %d queries executed.\n", result.NumQueries)
 func (c *Client) Import(ctx context.Context, databaseID, sqlFilePath string) (*ImportResult, error) {
+	if err := c.checkOperationDeadline(ctx, "import"); err != nil {
+		return nil, err
+	}
+
 	// Calculate MD5 hash of the file
 	fileHash, err := calculateMD5(sqlFilePath)
 	if err != nil {
+		var fileErr *ImportFileError
+		if errors.As(err, &fileErr) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to calculate MD5: %w", err)
 	}
 
@@ -94,6 +110,7 @@ func (c *Client) Import(ctx context.Context, databaseID, sqlFilePath string) (*I
 
 	// Poll for status updates
 	finalResp, err := c.pollImportStatus(ctx, path, firstPollResp)
+	c.detailsCache.invalidate(databaseID)
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +145,10 @@ func (c *Client) importInit(ctx context.Context, path, fileHash string) (*import
 	return &response, nil
 }
 
+// uploadFileToR2 uploads the SQL file at filePath to the presigned uploadURL
+// returned by the import API. The file is sent as-is: the API expects the
+// object to be the exact file whose MD5 hash was given to it, and does not
+// accept compressed uploads.
 func uploadFileToR2(ctx context.Context, uploadURL, filePath string) error {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -214,7 +235,7 @@ func (c *Client) pollImportStatus(ctx context.Context, path string, initialResp
 func calculateMD5(filePath string) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return "", err
+		return "", &ImportFileError{Path: filePath, Err: err}
 	}
 	defer file.Close()
 
@@ -0,0 +1,29 @@
+package cfd1
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportMissingFile(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+	})
+	path := filepath.Join(t.TempDir(), "missing.sql")
+
+	_, err := client.Import(context.Background(), "db", path)
+	var fileErr *ImportFileError
+	if !errors.As(err, &fileErr) {
+		t.Fatalf("expected ImportFileError, got %v", err)
+	}
+	if fileErr.Path != path {
+		t.Errorf("unexpected path: got %q, want %q", fileErr.Path, path)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
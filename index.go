@@ -0,0 +1,92 @@
+package cfd1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// indexesSQL lists the indexes of a table, with a row for each indexed column.
+const indexesSQL = `SELECT il.name, il."unique", il.origin, il.partial, ii.name ` +
+	`FROM pragma_index_list(?1) il JOIN pragma_index_info(il.name) ii ORDER BY il.name, ii.seqno`
+
+// IndexInfo describes an index of a table, as returned by [Handle.Indexes].
+type IndexInfo struct {
+	Name    string   // Name of the index
+	Table   string   // Table the index belongs to
+	Unique  bool     // Whether the index enforces uniqueness
+	Origin  string   // "c" if created by CREATE INDEX, "u" for a UNIQUE constraint, or "pk" for a PRIMARY KEY
+	Partial bool     // Whether the index has a WHERE clause
+	Columns []string // Indexed columns, in order; expressions are omitted, so empty if every key is an expression
+}
+
+// Indexes returns the indexes of table, ordered by name, including those
+// created automatically for UNIQUE and PRIMARY KEY constraints, which can be
+// told apart by their Origin. Together with [Handle.CreateIndex] and
+// [Handle.DropIndex], this allows the indexes of a table to be reconciled with
+// a desired set. If the table does not exist, no indexes are returned.
+func (h *Handle) Indexes(ctx context.Context, table string) ([]IndexInfo, error) {
+	result, err := h.rawQuery(WithoutDefaultLimit(ctx), indexesSQL, table)
+	if err != nil {
+		return nil, fmt.Errorf("listing indexes of %s: %w", table, err)
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+
+	var indexes []IndexInfo
+	for _, row := range result[0].Results.Rows {
+		var name, origin, column string
+		var unique, partial bool
+		for i, dest := range []any{&name, &unique, &origin, &partial, &column} {
+			if err := assign(dest, row[i]); err != nil {
+				return nil, fmt.Errorf("listing indexes of %s: column %d: %w", table, i, err)
+			}
+		}
+		if n := len(indexes); n == 0 || indexes[n-1].Name != name {
+			indexes = append(indexes, IndexInfo{Name: name, Table: table, Unique: unique, Origin: origin, Partial: partial})
+		}
+		// pragma_index_info gives no name for a key that is an expression
+		if row[4] != nil {
+			idx := &indexes[len(indexes)-1]
+			idx.Columns = append(idx.Columns, column)
+		}
+	}
+	return indexes, nil
+}
+
+// CreateIndex creates an index named name on the given columns of table, and
+// returns the [QueryMeta] of the statement, which reports the time taken to
+// build the index. If unique is true, the index is a UNIQUE index. The names
+// are quoted with [QuoteIdentifier], so they are matched exactly and cannot
+// inject SQL; columns must therefore be plain column names, not expressions.
+// It is an error if an index with the same name already exists.
+func (h *Handle) CreateIndex(ctx context.Context, name, table string, columns []string, unique bool) (QueryMeta, error) {
+	if err := checkIdentifiers(append([]string{name, table}, columns...)...); err != nil {
+		return QueryMeta{}, fmt.Errorf("creating index: %w", err)
+	}
+	if len(columns) == 0 {
+		return QueryMeta{}, fmt.Errorf("creating index %s: no columns", name)
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = QuoteIdentifier(col)
+	}
+	sql := "CREATE INDEX "
+	if unique {
+		sql = "CREATE UNIQUE INDEX "
+	}
+	sql += QuoteIdentifier(name) + " ON " + QuoteIdentifier(table) + " (" + strings.Join(quoted, ", ") + ")"
+	return h.ExecuteWithMeta(ctx, sql)
+}
+
+// DropIndex drops the index named name, and returns the [QueryMeta] of the
+// statement. The name is quoted with [QuoteIdentifier]. It is an error if the
+// index does not exist, so that a misspelled name is not silently ignored.
+func (h *Handle) DropIndex(ctx context.Context, name string) (QueryMeta, error) {
+	if err := checkIdentifiers(name); err != nil {
+		return QueryMeta{}, fmt.Errorf("dropping index: %w", err)
+	}
+	return h.ExecuteWithMeta(ctx, "DROP INDEX "+QuoteIdentifier(name))
+}
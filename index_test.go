@@ -0,0 +1,90 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestIndexes(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.SQL != indexesSQL || !reflect.DeepEqual(req.Params, []any{"users"}) {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult(
+			[]string{"name", "unique", "origin", "partial", "name"},
+			[]any{"idx_users_lower_email", 0.0, "c", 0.0, nil},
+			[]any{"idx_users_name", 0.0, "c", 0.0, "last"},
+			[]any{"idx_users_name", 0.0, "c", 0.0, "first"},
+			[]any{"sqlite_autoindex_users_1", 1.0, "u", 0.0, "email"},
+		)}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	got, err := h.Indexes(context.Background(), "users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []IndexInfo{
+		{Name: "idx_users_lower_email", Table: "users", Origin: "c"},
+		{Name: "idx_users_name", Table: "users", Origin: "c", Columns: []string{"last", "first"}},
+		{Name: "sqlite_autoindex_users_1", Table: "users", Unique: true, Origin: "u", Columns: []string{"email"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Indexes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCreateAndDropIndex(t *testing.T) {
+	var sql string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sql = req.SQL
+		writeAPIResult(w, []RawQueryResult{{Meta: QueryMeta{Duration: 4}, Success: true}}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		call    func() (QueryMeta, error)
+		sql     string
+		wantErr bool
+	}{
+		{"Create", func() (QueryMeta, error) {
+			return h.CreateIndex(ctx, "idx_name", "users", []string{"last", "first"}, false)
+		}, `CREATE INDEX "idx_name" ON "users" ("last", "first")`, false},
+		{"Create unique", func() (QueryMeta, error) {
+			return h.CreateIndex(ctx, "idx_email", "users", []string{`e"mail`}, true)
+		}, `CREATE UNIQUE INDEX "idx_email" ON "users" ("e""mail")`, false},
+		{"No columns", func() (QueryMeta, error) {
+			return h.CreateIndex(ctx, "idx", "users", nil, false)
+		}, "", true},
+		{"Empty name", func() (QueryMeta, error) {
+			return h.CreateIndex(ctx, "", "users", []string{"a"}, false)
+		}, "", true},
+		{"Drop", func() (QueryMeta, error) {
+			return h.DropIndex(ctx, "idx_name")
+		}, `DROP INDEX "idx_name"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql = ""
+			meta, err := tt.call()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if sql != tt.sql {
+				t.Errorf("SQL = %q, want %q", sql, tt.sql)
+			}
+			if !tt.wantErr && meta.Duration != 4 {
+				t.Errorf("unexpected meta: %+v", meta)
+			}
+		})
+	}
+}
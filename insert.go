@@ -0,0 +1,214 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"iter"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// InsertMany inserts rows into table, assigning the values of each row to
+// columns in order, and returns the rowid of each inserted row, in the same
+// order as rows. Each row is inserted by its own INSERT statement, so that its
+// rowid is reported as the last_row_id of that statement, and the statements
+// are sent with [Handle.Batch], as many to a request as there are rows in 100
+// bound parameters. Each request is a transaction, but the requests are not
+// atomic as a whole.
+//
+// The table must have a rowid, so WITHOUT ROWID tables are not supported; for
+// tables with an INTEGER PRIMARY KEY, the rowid is the primary key.
+//
+// Example usage:
+//
+//	ids, err := h.InsertMany(ctx, "users", []string{"name", "email"}, [][]any{
+//	    {"alice", "alice@example.com"},
+//	    {"bob", "bob@example.com"},
+//	})
+func (h *Handle) InsertMany(ctx context.Context, table string, columns []string, rows [][]any) ([]int64, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns to insert")
+	}
+	limit := h.client.paramLimit()
+	if len(columns) > limit {
+		return nil, fmt.Errorf("too many columns: %d, maximum is %d", len(columns), limit)
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = QuoteIdentifier(col)
+	}
+	sql := "INSERT INTO " + QuoteIdentifier(table) + " (" + strings.Join(quoted, ", ") + ") VALUES (" +
+		strings.Repeat("?, ", len(columns)-1) + "?)"
+	chunkSize := limit / len(columns)
+	if h.client.maxResultSets > 0 {
+		chunkSize = min(chunkSize, h.client.maxResultSets)
+	}
+
+	ids := make([]int64, 0, len(rows))
+	for start := 0; start < len(rows); start += chunkSize {
+		chunk := rows[start:min(start+chunkSize, len(rows))]
+		statements := make([]Statement, len(chunk))
+		for i, row := range chunk {
+			if len(row) != len(columns) {
+				return ids, fmt.Errorf("row %d has %d values, expected %d", start+i, len(row), len(columns))
+			}
+			statements[i] = Statement{SQL: sql, Params: row}
+		}
+
+		results, err := h.Batch(ctx, statements)
+		if err != nil {
+			return ids, fmt.Errorf("inserting rows %d to %d: %w", start, start+len(chunk)-1, err)
+		}
+		for _, result := range results {
+			ids = append(ids, int64(result.Meta.LastRowID))
+		}
+	}
+	return ids, nil
+}
+
+// BulkLoadProgress is called by [Handle.BulkLoad] after each batch of rows is
+// inserted, with the total number of rows loaded so far.
+type BulkLoadProgress func(loaded int64)
+
+// BulkLoad inserts the rows produced by rows into table, and returns the number
+// of rows loaded. Each row holds a value for every column of the table, in
+// order; all rows must have the same number of values. Rows are consumed as
+// they are produced and buffered into multi-row INSERT statements of up to
+// 100KB, which are executed as they fill, so streams of millions of rows can
+// be loaded without holding them in memory. If progress is non-nil, it is
+// called after each batch is inserted.
+//
+// To fit as many rows as possible in each request, values are embedded in the
+// SQL as literals rather than bound as parameters. Values of the types accepted
+// as query parameters are supported, other than values that cannot be
+// represented as SQLite literals, such as NaN and infinite floats. Each batch
+// is atomic, but the load as a whole is not: if a batch fails, the rows of
+// earlier batches remain inserted, and the number of rows loaded before the
+// failure is returned with the error. Unlike [Handle.Import], which uses the
+// bulk import API, the database remains available during the load.
+//
+// Example usage:
+//
+//	n, err := h.BulkLoad(ctx, "events", func(yield func([]any) bool) {
+//	    for rec := range decoder.Records() {
+//	        if !yield([]any{rec.ID, strings.ToLower(rec.Name), rec.Time}) {
+//	            return
+//	        }
+//	    }
+//	}, nil)
+func (h *Handle) BulkLoad(ctx context.Context, table string, rows iter.Seq[[]any], progress BulkLoadProgress) (int64, error) {
+	prefix := "INSERT INTO " + QuoteIdentifier(table) + " VALUES "
+	limit := MaxQuerySize - h.client.querySizeOverhead()
+	var sb strings.Builder
+	var loaded, pending int64
+	numCols := -1
+
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		if _, err := h.rawQuery(ctx, sb.String()); err != nil {
+			return fmt.Errorf("loading rows %d to %d: %w", loaded, loaded+pending-1, err)
+		}
+		loaded += pending
+		pending = 0
+		sb.Reset()
+		if progress != nil {
+			progress(loaded)
+		}
+		return nil
+	}
+
+	for row := range rows {
+		n := loaded + pending
+		if numCols < 0 {
+			if len(row) == 0 {
+				return loaded, fmt.Errorf("row %d has no values", n)
+			}
+			numCols = len(row)
+		} else if len(row) != numCols {
+			return loaded, fmt.Errorf("row %d has %d values, expected %d", n, len(row), numCols)
+		}
+		tuple, err := rowLiteral(row)
+		if err != nil {
+			return loaded, fmt.Errorf("row %d: %w", n, err)
+		}
+		if len(prefix)+len(tuple) > limit {
+			if sent, _ := h.client.sentSQL(ctx, prefix+tuple); len(sent) > MaxQuerySize {
+				return loaded, fmt.Errorf("row %d: %w", n, &QueryTooLargeError{Size: len(sent), Max: MaxQuerySize})
+			}
+		}
+		if pending > 0 && sb.Len()+len(", ")+len(tuple) > limit {
+			if err := flush(); err != nil {
+				return loaded, err
+			}
+		}
+		if pending == 0 {
+			sb.WriteString(prefix)
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(tuple)
+		pending++
+	}
+	if err := flush(); err != nil {
+		return loaded, err
+	}
+	return loaded, nil
+}
+
+// rowLiteral returns row as a parenthesized list of SQL literals.
+func rowLiteral(row []any) (string, error) {
+	values, err := convertTypes(row)
+	if err != nil {
+		return "", err
+	}
+	literals := make([]string, len(values))
+	for i, v := range values {
+		if literals[i], err = sqlLiteral(v); err != nil {
+			return "", fmt.Errorf("value %d: %w", i+1, err)
+		}
+	}
+	return "(" + strings.Join(literals, ", ") + ")", nil
+}
+
+// sqlLiteral returns v, a query parameter value after conversion by
+// convertTypes, as an SQL literal.
+func sqlLiteral(v any) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		if strings.ContainsRune(val, 0) {
+			return "", fmt.Errorf("cannot represent text containing NUL as an SQL literal")
+		}
+		return quoteLiteral(val), nil
+	case []byte:
+		return "X'" + hex.EncodeToString(val) + "'", nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("cannot represent %v as an SQL literal", f)
+		}
+		s := strconv.FormatFloat(f, 'g', -1, rv.Type().Bits())
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0" // keep REAL affinity for whole numbers
+		}
+		return s, nil
+	case reflect.String:
+		return sqlLiteral(rv.String())
+	}
+	return "", fmt.Errorf("unsupported value type %T", v)
+}
@@ -0,0 +1,171 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInsertMany(t *testing.T) {
+	var requests []batchRequest
+	nextID := 1
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req batchRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		results := make([]RawQueryResult, len(req.Batch))
+		for i, stmt := range req.Batch {
+			if len(stmt.Params) != 3 {
+				t.Errorf("unexpected params: %v", stmt.Params)
+			}
+			results[i] = rawResult(nil)
+			results[i].Meta = QueryMeta{Changes: 1, LastRowID: nextID}
+			nextID++
+		}
+		writeAPIResult(w, results, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	rows := make([][]any, 50)
+	want := make([]int64, 50)
+	for i := range rows {
+		rows[i] = []any{i, "name", "email"}
+		want[i] = int64(i + 1)
+	}
+	ids, err := h.InsertMany(context.Background(), "users", []string{"id", "name", "email"}, rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("unexpected IDs: got %v, want %v", ids, want)
+	}
+	if len(requests) != 2 || len(requests[0].Batch) != 33 || len(requests[1].Batch) != 17 {
+		t.Fatalf("unexpected requests: %d", len(requests))
+	}
+	if sql := `INSERT INTO "users" ("id", "name", "email") VALUES (?, ?, ?)`; requests[0].Batch[0].SQL != sql {
+		t.Errorf("unexpected SQL: %q", requests[0].Batch[0].SQL)
+	}
+	if got := requests[1].Batch[0].Params[0]; got != 33.0 {
+		t.Errorf("second request starts with row %v, want 33", got)
+	}
+}
+
+func TestSQLLiteral(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+		wantErr  bool
+	}{
+		{"Nil", nil, "NULL", false},
+		{"Int", int64(-42), "-42", false},
+		{"Uint", uint8(7), "7", false},
+		{"Float", 1.5, "1.5", false},
+		{"Whole float", 2.0, "2.0", false},
+		{"Large float", 1e20, "1e+20", false},
+		{"String", "it's", "'it''s'", false},
+		{"Named string", TestBaseString("x"), "'x'", false},
+		{"Bytes", []byte{0xde, 0xad}, "X'dead'", false},
+		{"NaN", math.NaN(), "", true},
+		{"NUL", "a\x00b", "", true},
+		{"Unsupported", struct{}{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sqlLiteral(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sqlLiteral() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("sqlLiteral() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBulkLoad(t *testing.T) {
+	var requests []rawQueryRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		if len(req.SQL) > MaxQuerySize {
+			t.Errorf("query too large: %d bytes", len(req.SQL))
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult(nil)}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	const numRows = 5000
+	name := strings.Repeat("n", 50)
+	rows := func(yield func([]any) bool) {
+		for i := range numRows {
+			if !yield([]any{i, name, i%2 == 0}) {
+				return
+			}
+		}
+	}
+	var reported []int64
+	n, err := h.BulkLoad(context.Background(), "t", rows, func(loaded int64) {
+		reported = append(reported, loaded)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != numRows {
+		t.Errorf("loaded %d rows, want %d", n, numRows)
+	}
+	if len(requests) < 2 {
+		t.Fatalf("expected multiple batches, got %d", len(requests))
+	}
+	if len(reported) != len(requests) || reported[len(reported)-1] != numRows {
+		t.Errorf("unexpected progress: %v", reported)
+	}
+	if want := `INSERT INTO "t" VALUES (0, '` + name + `', 1), (1, '`; !strings.HasPrefix(requests[0].SQL, want) {
+		t.Errorf("unexpected SQL: %.100s", requests[0].SQL)
+	}
+
+	_, err = h.BulkLoad(context.Background(), "t", func(yield func([]any) bool) {
+		_ = yield([]any{1, 2}) && yield([]any{3})
+	}, nil)
+	if err == nil {
+		t.Error("expected error for mismatched row length")
+	}
+}
+
+func TestBulkLoadImplicitTransactions(t *testing.T) {
+	var sizes []int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sizes = append(sizes, len(req.SQL))
+		writeAPIResult(w, []RawQueryResult{rawResult(nil), rawResult(nil), rawResult(nil)}, nil)
+	}, WithImplicitTransactions())
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	// Eleven rows of this size fill an INSERT of exactly MaxQuerySize bytes,
+	// which is too large once wrapped in a transaction
+	value := strings.Repeat("x", 9083)
+	n, err := h.BulkLoad(context.Background(), "t", func(yield func([]any) bool) {
+		for range 22 {
+			if !yield([]any{value}) {
+				return
+			}
+		}
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 22 {
+		t.Errorf("loaded %d rows, want 22", n)
+	}
+	for i, size := range sizes {
+		if size > MaxQuerySize {
+			t.Errorf("batch %d is %d bytes", i, size)
+		}
+	}
+}
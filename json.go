@@ -0,0 +1,84 @@
+package cfd1
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONExtract returns an SQL expression that extracts the value at path from the
+// JSON document stored in column, using SQLite's json_extract function. The
+// column name is quoted with [QuoteIdentifier] and the path is embedded as a
+// string literal, so neither can be used for SQL injection.
+//
+// json_extract returns JSON objects and arrays as TEXT, JSON numbers as INTEGER
+// or REAL, JSON booleans as the integers 1 and 0, and JSON null (or a missing
+// path) as NULL. When scanned, TEXT holding a JSON object or array can be
+// decoded directly into a map, slice, or struct destination.
+//
+// Example usage:
+//
+//	expr := cfd1.JSONExtract("doc", "$.address.city")
+//	// json_extract("doc", '$.address.city')
+func JSONExtract(column, path string) string {
+	return fmt.Sprintf("json_extract(%s, %s)", QuoteIdentifier(column), quoteLiteral(path))
+}
+
+// QuerySelectJSON executes a query that selects the value at path within the
+// JSON document stored in column, and returns a Rows object with a single
+// column of results. The from parameter is the remainder of the query after
+// FROM, such as a table name optionally followed by a WHERE clause; any
+// placeholders it contains are bound to params.
+//
+// Example usage:
+//
+//	rows := h.QuerySelectJSON(ctx, "doc", "$.name", "people WHERE id > ?", 100)
+//	for rows.Next() {
+//	    var name string
+//	    if err := rows.Scan(&name); err != nil {
+//	        // handle error
+//	    }
+//	}
+func (h *Handle) QuerySelectJSON(ctx context.Context, column, path, from string, params ...any) *Rows {
+	sql := "SELECT " + JSONExtract(column, path) + " FROM " + from
+	return h.QueryRows(ctx, sql, params...)
+}
+
+// JSONParam is a query parameter that is sent as a single TEXT value holding
+// the JSON encoding of a Go value. Create one with [AsJSON].
+type JSONParam struct {
+	v any
+}
+
+// AsJSON returns a query parameter whose value is v encoded as JSON with
+// encoding/json, and bound as a single TEXT value. This allows an array or
+// object to be passed to SQLite's JSON functions, such as json_each, as one
+// parameter, rather than requiring a placeholder per element. To expand a slice
+// into an IN list with one placeholder per element instead, use [QueryIn]. If
+// v cannot be encoded, the query fails without being sent.
+//
+// AsJSON can also be used with the database/sql driver, as JSONParam
+// implements [driver.Valuer].
+//
+// Example usage:
+//
+//	rows := h.QueryRows(ctx, "SELECT value FROM json_each(?)", cfd1.AsJSON([]int{1, 2, 3}))
+func AsJSON(v any) JSONParam {
+	return JSONParam{v: v}
+}
+
+// Value returns the JSON encoding of the parameter's value as a string.
+func (p JSONParam) Value() (driver.Value, error) {
+	b, err := json.Marshal(p.v)
+	if err != nil {
+		return nil, fmt.Errorf("encoding JSON parameter: %w", err)
+	}
+	return string(b), nil
+}
+
+// quoteLiteral quotes s for use as an SQL string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,102 @@
+package cfd1
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testJSONAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+func TestJSONExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   string
+		path     string
+		expected string
+	}{
+		{"Simple path", "doc", "$.name", `json_extract("doc", '$.name')`},
+		{"Nested path", "doc", "$.address.city", `json_extract("doc", '$.address.city')`},
+		{"Array index", "doc", "$.tags[0]", `json_extract("doc", '$.tags[0]')`},
+		{"Quote in column", `we"ird`, "$.a", `json_extract("we""ird", '$.a')`},
+		{"Quote in path", "doc", `$."it's"`, `json_extract("doc", '$."it''s"')`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := JSONExtract(tt.column, tt.path)
+			if got != tt.expected {
+				t.Errorf("unexpected result: got %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestAssignJSONExtract checks that values shaped like json_extract results, as
+// decoded from the D1 API, scan into the expected Go types.
+func TestAssignJSONExtract(t *testing.T) {
+	tests := []struct {
+		name        string
+		dest        any
+		src         any
+		expected    any
+		expectError bool
+	}{
+		{"JSON string to string", new(string), "Alice", "Alice", false},
+		{"JSON integer to int", new(int), float64(42), 42, false},
+		{"JSON integer to int64", new(int64), float64(42), int64(42), false},
+		{"JSON real to float64", new(float64), 2.5, 2.5, false},
+		{"JSON true to bool", new(bool), float64(1), true, false},
+		{"JSON false to bool", new(bool), float64(0), false, false},
+		{"JSON null to string", new(string), nil, "", false},
+		{"JSON null to int", new(int), nil, 0, false},
+		{"JSON object to string", new(string), `{"a":1}`, `{"a":1}`, false},
+		{"JSON object to map", new(map[string]any), `{"a":1}`, map[string]any{"a": float64(1)}, false},
+		{"JSON array to slice", new([]int), `[1,2,3]`, []int{1, 2, 3}, false},
+		{"JSON object to struct", new(testJSONAddress), `{"city":"Paris","zip":"75001"}`, testJSONAddress{City: "Paris", Zip: "75001"}, false},
+		{"Invalid JSON to map", new(map[string]any), `not json`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := assign(tt.dest, tt.src)
+			if (err != nil) != tt.expectError {
+				t.Errorf("unexpected error state: got %v, want error: %v", err, tt.expectError)
+			}
+			if !tt.expectError {
+				destVal := reflect.ValueOf(tt.dest).Elem().Interface()
+				if !reflect.DeepEqual(destVal, tt.expected) {
+					t.Errorf("unexpected result: got %v, want %v", destVal, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestAsJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   []any
+		expected []any
+		wantErr  bool
+	}{
+		{"Array", []any{AsJSON([]int{1, 2, 3})}, []any{"[1,2,3]"}, false},
+		{"Object", []any{1, AsJSON(map[string]string{"a": "b"})}, []any{1, `{"a":"b"}`}, false},
+		{"Nil", []any{AsJSON(nil)}, []any{"null"}, false},
+		{"Unencodable", []any{AsJSON(make(chan int))}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertTypes(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertTypes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("convertTypes() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
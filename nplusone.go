@@ -0,0 +1,131 @@
+package cfd1
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NPlusOneHandler is a function called when the N+1 query detector enabled
+// with [WithNPlusOneDetector] sees a query of the same shape executed count
+// times within its window, in a single scope created by [WithNPlusOneScope]. The shape is the query with its literals and
+// placeholders replaced with ?, as described for [WithNPlusOneDetector].
+type NPlusOneHandler func(shape string, count int)
+
+// WithNPlusOneDetector enables detection of N+1 query patterns, in which a
+// query of the same shape is executed repeatedly, typically once per row of an
+// earlier result, when a single query with a join or IN list would do. Each
+// query is normalized by replacing literals and placeholders with ?, so that
+// queries differing only in their values have the same shape. When the same
+// shape is executed threshold times within window, it is reported to the
+// handler set with [WithNPlusOneHandler], at most once per window. Queries are
+// not counted or reported unless both options are set.
+//
+// Queries are counted per scope, such as a single request handled by a server,
+// so that queries of the same shape from concurrent, unrelated work are not
+// counted together. A scope is created with [WithNPlusOneScope], and includes
+// the queries made with its context and the contexts derived from it; queries
+// made outside of any scope are not counted. The detector is intended for
+// development and testing, and is off by default.
+func WithNPlusOneDetector(threshold int, window time.Duration) ClientOption {
+	return func(c *Client) {
+		c.nPlusOne = &nPlusOneDetector{
+			threshold: threshold,
+			window:    window,
+		}
+	}
+}
+
+// nPlusOneScopeKey is the context key set by [WithNPlusOneScope].
+type nPlusOneScopeKey struct{}
+
+// WithNPlusOneScope returns a copy of ctx that begins a new scope for the N+1
+// query detector enabled with [WithNPlusOneDetector]. Queries of the same shape
+// are only reported if they are repeated within one scope, so a scope should
+// cover a single unit of work, such as the handling of one request:
+//
+//	func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//	    ctx := cfd1.WithNPlusOneScope(r.Context())
+//	    ...
+//	}
+//
+// If ctx is already in a scope, the returned context is in the new scope only.
+func WithNPlusOneScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nPlusOneScopeKey{}, &nPlusOneScope{shapes: make(map[string]*shapeCount)})
+}
+
+// WithNPlusOneHandler sets an [NPlusOneHandler] to receive the warnings of the
+// detector enabled with [WithNPlusOneDetector]. The handler is called
+// synchronously before the query is sent, and may be called concurrently if
+// the client is used concurrently. It has no effect unless the detector is
+// also enabled.
+func WithNPlusOneHandler(handler NPlusOneHandler) ClientOption {
+	return func(c *Client) {
+		c.nPlusOneHandler = handler
+	}
+}
+
+// nPlusOneSweepSize is the number of tracked shapes above which expired shapes
+// are discarded.
+const nPlusOneSweepSize = 1000
+
+// nPlusOneDetector holds the settings of the N+1 query detector.
+type nPlusOneDetector struct {
+	threshold int
+	window    time.Duration
+}
+
+// nPlusOneScope counts executions of each query shape within a scope.
+type nPlusOneScope struct {
+	mu     sync.Mutex
+	shapes map[string]*shapeCount
+}
+
+// shapeCount counts the executions of a query shape since start.
+type shapeCount struct {
+	start time.Time
+	count int
+}
+
+// record counts an execution of shape at now in scope, and returns the number
+// of executions of shape in the current window if it has just reached the
+// threshold, or zero otherwise.
+func (d *nPlusOneDetector) record(scope *nPlusOneScope, shape string, now time.Time) int {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	sc, ok := scope.shapes[shape]
+	if !ok || now.Sub(sc.start) > d.window {
+		if !ok && len(scope.shapes) >= nPlusOneSweepSize {
+			for s, other := range scope.shapes {
+				if now.Sub(other.start) > d.window {
+					delete(scope.shapes, s)
+				}
+			}
+		}
+		sc = &shapeCount{start: now}
+		scope.shapes[shape] = sc
+	}
+	sc.count++
+	if sc.count == d.threshold {
+		return sc.count
+	}
+	return 0
+}
+
+// checkNPlusOne counts an execution of sql in the N+1 detector scope of ctx,
+// if the client has a detector and a handler and ctx has a scope, and reports
+// the query's shape to the handler if it has reached the threshold.
+func (c *Client) checkNPlusOne(ctx context.Context, sql string) {
+	if c.nPlusOne == nil || c.nPlusOne.threshold <= 0 || c.nPlusOneHandler == nil {
+		return
+	}
+	scope, ok := ctx.Value(nPlusOneScopeKey{}).(*nPlusOneScope)
+	if !ok {
+		return
+	}
+	shape := normalizeSQL(sql)
+	if count := c.nPlusOne.record(scope, shape, time.Now()); count > 0 {
+		c.nPlusOneHandler(shape, count)
+	}
+}
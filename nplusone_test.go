@@ -0,0 +1,85 @@
+package cfd1
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNPlusOneDetector(t *testing.T) {
+	var shapes []string
+	var counts []int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"name"})}, nil)
+	}, WithNPlusOneDetector(3, time.Minute), WithNPlusOneHandler(func(shape string, count int) {
+		shapes = append(shapes, shape)
+		counts = append(counts, count)
+	}))
+	ctx := WithNPlusOneScope(context.Background())
+
+	for i := range 5 {
+		if _, err := client.RawQuery(ctx, "db", "SELECT name FROM users WHERE id = ?", i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.RawQuery(ctx, "db", "SELECT 1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(shapes) != 2 {
+		t.Fatalf("got %d warnings, want 2: %q", len(shapes), shapes)
+	}
+	if shapes[0] != "SELECT NAME FROM USERS WHERE ID = ?" || counts[0] != 3 {
+		t.Errorf("unexpected warning: %q (%d)", shapes[0], counts[0])
+	}
+
+	// Queries in different scopes, or in none, are not counted together
+	shapes = nil
+	for i := range 5 {
+		for _, ctx := range []context.Context{WithNPlusOneScope(context.Background()), context.Background()} {
+			if _, err := client.RawQuery(ctx, "db", "SELECT name FROM users WHERE id = ?", i); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	if len(shapes) != 0 {
+		t.Errorf("unexpected warnings across scopes: %q", shapes)
+	}
+}
+
+func TestNPlusOneDetectorWithoutHandler(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"})}, nil)
+	}, WithNPlusOneDetector(2, time.Minute))
+
+	// Without a handler, queries are not counted
+	ctx := WithNPlusOneScope(context.Background())
+	for range 3 {
+		if _, err := client.RawQuery(ctx, "db", "SELECT 1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := len(ctx.Value(nPlusOneScopeKey{}).(*nPlusOneScope).shapes); n != 0 {
+		t.Errorf("detector tracked %d shapes without a handler", n)
+	}
+}
+
+func TestNPlusOneDetectorWindow(t *testing.T) {
+	d := &nPlusOneDetector{threshold: 2, window: time.Second}
+	scope := &nPlusOneScope{shapes: make(map[string]*shapeCount)}
+	start := time.Now()
+	steps := []struct {
+		offset   time.Duration
+		expected int
+	}{
+		{0, 0},
+		{2 * time.Second, 0}, // window expired; count restarts
+		{2500 * time.Millisecond, 2},
+		{2600 * time.Millisecond, 0}, // already reported in this window
+	}
+	for i, step := range steps {
+		if got := d.record(scope, "SELECT ?", start.Add(step.offset)); got != step.expected {
+			t.Errorf("step %d: record() = %d, want %d", i, got, step.expected)
+		}
+	}
+}
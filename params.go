@@ -0,0 +1,97 @@
+package cfd1
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ParamKind is the style of a parameter placeholder in an SQL query.
+type ParamKind int
+
+const (
+	ParamAnonymous ParamKind = iota // ?
+	ParamNumbered                   // ?NNN
+	ParamNamed                      // :name, @name, or $name
+)
+
+// String returns the name of the placeholder style.
+func (k ParamKind) String() string {
+	switch k {
+	case ParamAnonymous:
+		return "anonymous"
+	case ParamNumbered:
+		return "numbered"
+	case ParamNamed:
+		return "named"
+	default:
+		return "ParamKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// ParamInfo describes a parameter placeholder found in an SQL query by
+// [Handle.AnalyzeParams].
+type ParamInfo struct {
+	Text   string    // the placeholder as written, such as "?", "?3", or ":id"
+	Offset int       // byte offset of the placeholder within the query
+	Kind   ParamKind // the placeholder's style
+	Name   string    // for named placeholders, the name without its prefix
+	Index  int       // 1-based position in the parameters of the bound value
+}
+
+// AnalyzeParams returns the parameter placeholders in sql, in the order they
+// appear, with the position in a query's parameters of the value each binds.
+// Placeholders within string literals, quoted identifiers, and comments are
+// not included. The query is not sent, so AnalyzeParams can be used to check a
+// dynamically built query before executing it.
+//
+// Placeholders are numbered as SQLite numbers them, which is how D1 binds the
+// positional parameters of a query:
+//
+//   - ?NNN binds parameter NNN.
+//   - A named placeholder binds the same parameter as earlier uses of the same
+//     name, including its prefix, so :a and @a are different parameters.
+//   - Any other placeholder, ? or the first use of a name, binds one more than
+//     the largest number used before it.
+//
+// So in "SELECT ?, :a, ?5, ?, :a", the placeholders bind parameters 1, 2, 5, 6,
+// and 2. When a query's parameters are given with [Named], its named
+// placeholders are numbered in the same way, and the values are put in that
+// order.
+//
+// AnalyzeParams returns an error for the invalid placeholder ?0, and a
+// [TooManyParamsError] if the query binds more parameters than the client's
+// limit; see [WithMaxQueryParams].
+func (h *Handle) AnalyzeParams(sql string) ([]ParamInfo, error) {
+	return analyzeParams(sql, h.client.paramLimit())
+}
+
+// analyzeParams returns the parameter placeholders in sql, checking that none
+// binds a parameter numbered above limit.
+func analyzeParams(sql string, limit int) ([]ParamInfo, error) {
+	tokens := tokenizeSQL(sql)
+	numbers := paramNumbers(tokens)
+	var params []ParamInfo
+	for i, t := range tokens {
+		if t.kind != tokenParam {
+			continue
+		}
+		p := ParamInfo{Text: t.text, Offset: t.pos, Index: numbers[i]}
+		switch {
+		case t.text == "?":
+			p.Kind = ParamAnonymous
+		case t.text[0] == '?':
+			p.Kind = ParamNumbered
+		default:
+			p.Kind = ParamNamed
+			p.Name = t.text[1:]
+		}
+		if p.Index < 1 {
+			return nil, fmt.Errorf("invalid placeholder %s at offset %d", t.text, t.pos)
+		}
+		if p.Index > limit {
+			return nil, &TooManyParamsError{Count: p.Index, Max: limit}
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
@@ -0,0 +1,64 @@
+package cfd1
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []ParamInfo
+		wantErr  bool
+	}{
+		{
+			name: "Mixed styles",
+			sql:  "SELECT ?, :a, ?5, ?, :a, @a",
+			expected: []ParamInfo{
+				{Text: "?", Offset: 7, Kind: ParamAnonymous, Index: 1},
+				{Text: ":a", Offset: 10, Kind: ParamNamed, Name: "a", Index: 2},
+				{Text: "?5", Offset: 14, Kind: ParamNumbered, Index: 5},
+				{Text: "?", Offset: 18, Kind: ParamAnonymous, Index: 6},
+				{Text: ":a", Offset: 21, Kind: ParamNamed, Name: "a", Index: 2},
+				{Text: "@a", Offset: 25, Kind: ParamNamed, Name: "a", Index: 7},
+			},
+		},
+		{
+			name:     "Ignored in strings and comments",
+			sql:      "SELECT '?', \":a\" -- ?\n, $v /* ?9 */",
+			expected: []ParamInfo{{Text: "$v", Offset: 24, Kind: ParamNamed, Name: "v", Index: 1}},
+		},
+		{
+			name: "No placeholders",
+			sql:  "SELECT 1",
+		},
+		{
+			name:    "Zero",
+			sql:     "SELECT ?0",
+			wantErr: true,
+		},
+	}
+
+	client := NewClient("test-account", "test-token")
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.AnalyzeParams(tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("unexpected params:\ngot  %+v\nwant %+v", got, tt.expected)
+			}
+		})
+	}
+
+	_, err := h.AnalyzeParams("SELECT ?101")
+	var tmp *TooManyParamsError
+	if !errors.As(err, &tmp) || tmp.Count != 101 {
+		t.Errorf("expected TooManyParamsError with count 101, got %v", err)
+	}
+}
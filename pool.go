@@ -0,0 +1,53 @@
+package cfd1
+
+import (
+	"net/http"
+	"sync"
+)
+
+// ClientPool manages Clients for multiple Cloudflare accounts. All of its
+// clients share a single HTTP client, so that requests to the Cloudflare API
+// reuse one pool of connections rather than opening a separate pool per
+// account. A ClientPool is safe for concurrent use.
+type ClientPool struct {
+	httpClient *http.Client
+	options    []ClientOption
+	clients    map[string]*Client
+	mux        sync.Mutex
+}
+
+// NewClientPool returns a new ClientPool. The options are applied to every
+// Client created by the pool. Clients share a default HTTP client, or the one
+// given with [WithHTTPClient] if it is among the options.
+//
+// Example usage:
+//
+//	pool := cfd1.NewClientPool(cfd1.WithListConcurrency(4))
+//	for _, acct := range accounts {
+//	    client := pool.Client(acct.ID, acct.Token)
+//	    // use client
+//	}
+func NewClientPool(options ...ClientOption) *ClientPool {
+	return &ClientPool{
+		httpClient: defaultHTTPClient(),
+		options:    options,
+		clients:    make(map[string]*Client),
+	}
+}
+
+// Client returns the Client for the given account ID and API token, creating
+// it on first use. Later calls with the same account ID and token return the
+// same Client, so its row counters accumulate across calls.
+func (p *ClientPool) Client(accountID, apiToken string) *Client {
+	key := accountID + "\x00" + apiToken
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if c, ok := p.clients[key]; ok {
+		return c
+	}
+	options := append([]ClientOption{WithHTTPClient(p.httpClient)}, p.options...)
+	c := NewClient(accountID, apiToken, options...)
+	p.clients[key] = c
+	return c
+}
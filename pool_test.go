@@ -0,0 +1,39 @@
+package cfd1
+
+import (
+	"testing"
+)
+
+type nopDebugLogger struct{}
+
+func (nopDebugLogger) LogRequest(method string, url string, requestBody, responseBody []byte, statusCode int) {
+}
+
+func TestClientPool(t *testing.T) {
+	pool := NewClientPool(WithDebugLogger(nopDebugLogger{}))
+
+	a1 := pool.Client("account-a", "token-a")
+	a2 := pool.Client("account-a", "token-a")
+	b := pool.Client("account-b", "token-b")
+
+	if a1 != a2 {
+		t.Errorf("expected the same client for the same account")
+	}
+	if a1 == b {
+		t.Errorf("expected different clients for different accounts")
+	}
+
+	// Each client wraps the shared transport exactly once
+	for _, c := range []*Client{a1, b} {
+		dt, ok := c.httpClient.Transport.(*debugTransport)
+		if !ok {
+			t.Fatalf("expected debug transport, got %T", c.httpClient.Transport)
+		}
+		if dt.transport != pool.httpClient.Transport {
+			t.Errorf("expected shared underlying transport")
+		}
+	}
+	if _, ok := pool.httpClient.Transport.(*debugTransport); ok {
+		t.Errorf("shared HTTP client was modified")
+	}
+}
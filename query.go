@@ -1,12 +1,29 @@
 package cfd1
 
 import (
+	"bytes"
 	"context"
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// MaxQueryParams is the maximum number of bound parameters that D1 accepts in
+// a single query. Queries with more are rejected by the client before being
+// sent; see [WithMaxQueryParams] to use a different limit.
+const MaxQueryParams = 100
+
+// MaxQuerySize is the maximum size of the SQL text of a single query, in bytes,
+// that D1 accepts. Larger queries are rejected by the client before being sent.
+const MaxQuerySize = 100_000
+
 // QueryMeta represents metadata about a database query execution.
 type QueryMeta struct {
 	ChangedDB   bool    `json:"changed_db"`
@@ -18,16 +35,29 @@ type QueryMeta struct {
 	SizeAfter   int     `json:"size_after"`
 }
 
+// elapsed returns the query execution time reported in m.
+func (m QueryMeta) elapsed() time.Duration {
+	return time.Duration(m.Duration * float64(time.Millisecond))
+}
+
 // QueryResult represents the result of a database query. Each row is returned
-// as a map[string]any where the key is the column name.
+// as a map[string]any where the key is the column name. Columns holds the keys
+// of the maps in the order of the columns in the query, so rows can be rendered
+// in that order; it may be nil for results decoded from JSON. Numbers are int64
+// or float64, as in a [RawQueryResult].
 type QueryResult struct {
 	Meta    QueryMeta        `json:"meta"`
+	Columns []string         `json:"columns,omitempty"`
 	Results []map[string]any `json:"results"`
 	Success bool             `json:"success"`
 }
 
 // RawQueryResult represents the raw result of a database query. The row values
 // and column names are returned in separate structures.
+//
+// Numbers in Rows are int64 if they are integral, and float64 otherwise, so
+// that INTEGER values beyond the 53 bits of precision of a float64 are returned
+// exactly.
 type RawQueryResult struct {
 	Meta    QueryMeta `json:"meta"`
 	Results struct {
@@ -37,10 +67,81 @@ type RawQueryResult struct {
 	Success bool `json:"success"`
 }
 
-func convertTypes(input []any) []any {
+// UnmarshalJSON decodes a RawQueryResult, converting the numbers in its rows to
+// int64 or float64 without loss of precision.
+func (r *RawQueryResult) UnmarshalJSON(data []byte) error {
+	type plain RawQueryResult // without this method
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	for _, row := range r.Results.Rows {
+		for j, v := range row {
+			if n, ok := v.(json.Number); ok {
+				row[j] = numberValue(n)
+			}
+		}
+	}
+	return nil
+}
+
+// numberValue returns n as an int64 if it is an integer that fits in one, and
+// as a float64 otherwise.
+func numberValue(n json.Number) any {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// QuoteIdentifier quotes name for use as an SQL identifier, such as a table or
+// column name, by wrapping it in double quotes and escaping any embedded double
+// quotes. The entire name is treated as a single identifier.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// checkIdentifiers returns an error if any of names is empty, is not valid
+// UTF-8, or contains a NUL character, none of which can be used as an SQL
+// identifier, even when quoted.
+func checkIdentifiers(names ...string) error {
+	for _, name := range names {
+		if name == "" || !utf8.ValidString(name) || strings.ContainsRune(name, 0) {
+			return fmt.Errorf("invalid identifier %q", name)
+		}
+	}
+	return nil
+}
+
+// convertTypes converts query parameters to values that can be sent to the
+// API. Types registered with RegisterType are encoded by their registered
+// function, and other types implementing driver.Valuer by their Value method,
+// with nil pointers sent as NULL. The resulting time.Time values are converted
+// to Unix timestamps, and bool values to 1 or 0.
+func convertTypes(input []any) ([]any, error) {
 	result := make([]any, len(input))
 
 	for i, v := range input {
+		if encode := typeEncoder(v); encode != nil {
+			encoded, err := encode(v)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+			}
+			v = encoded
+		} else if valuer, ok := v.(driver.Valuer); ok {
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				v = nil
+			} else {
+				value, err := valuer.Value()
+				if err != nil {
+					return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+				}
+				v = value
+			}
+		}
+
 		switch val := v.(type) {
 		case time.Time:
 			result[i] = int(val.UTC().Unix())
@@ -55,26 +156,160 @@ func convertTypes(input []any) []any {
 		}
 	}
 
-	return result
+	return result, nil
+}
+
+// NamedArg is a query parameter bound to a named placeholder, such as :name,
+// @name, or $name. Use [Named] to create one.
+type NamedArg struct {
+	Name  string
+	Value any
+}
+
+// Named returns a parameter that binds value to the placeholders called name
+// in a query. The name may be given with or without its prefix, so
+// Named("id", 1) and Named(":id", 1) both bind :id, @id, and $id:
+//
+//	client.Query(ctx, dbID, "SELECT * FROM users WHERE id = :id", cfd1.Named("id", 1))
+//
+// Named parameters may also be given as a single map[string]any holding every
+// value. A query's parameters must be either all named or all positional.
+func Named(name string, value any) NamedArg {
+	return NamedArg{Name: name, Value: value}
+}
+
+// bindNamed rewrites the named placeholders in sql to numbered ones and returns
+// the positional parameters that bind them, if params holds named parameters,
+// either as [NamedArg] values or as a single map[string]any. Otherwise sql and
+// params are returned unchanged. It returns an error wrapping
+// [ErrMixedParams] if named and positional parameters are mixed, and an error
+// if a placeholder has no value or a value has no placeholder.
+func bindNamed(sql string, params []any) (string, []any, error) {
+	values, err := namedValues(params)
+	if values == nil || err != nil {
+		return sql, params, err
+	}
+
+	tokens := tokenizeSQL(sql)
+	numbers := paramNumbers(tokens)
+	var b strings.Builder
+	var bound []any
+	used := make(map[string]bool)
+	last := 0
+	for i, t := range tokens {
+		if numbers[i] == 0 {
+			continue
+		}
+		if t.text[0] == '?' {
+			return "", nil, fmt.Errorf("%w: query has positional placeholder %s", ErrMixedParams, t.text)
+		}
+		name := t.text[1:]
+		value, ok := values[name]
+		if !ok {
+			return "", nil, fmt.Errorf("no value given for named parameter %s", t.text)
+		}
+		used[name] = true
+		if numbers[i] > len(bound) {
+			bound = append(bound, value)
+		}
+		b.WriteString(sql[last:t.pos])
+		b.WriteString("?" + strconv.Itoa(numbers[i]))
+		last = t.pos + len(t.text)
+	}
+	b.WriteString(sql[last:])
+
+	for name := range values {
+		if !used[name] {
+			return "", nil, fmt.Errorf("named parameter %q is not used by the query", name)
+		}
+	}
+	return b.String(), bound, nil
+}
+
+// namedValues returns the values of named parameters in params by name, with
+// any prefix removed, or nil if params holds no named parameters.
+func namedValues(params []any) (map[string]any, error) {
+	if len(params) == 1 {
+		if m, ok := params[0].(map[string]any); ok {
+			values := make(map[string]any, len(m))
+			for name, v := range m {
+				if err := addNamedValue(values, name, v); err != nil {
+					return nil, err
+				}
+			}
+			return values, nil
+		}
+	}
+
+	var values map[string]any
+	for i, p := range params {
+		arg, ok := p.(NamedArg)
+		if !ok {
+			if values != nil {
+				return nil, fmt.Errorf("%w: parameter %d is positional", ErrMixedParams, i+1)
+			}
+			continue
+		}
+		if values == nil {
+			if i > 0 {
+				return nil, fmt.Errorf("%w: parameter %d is named", ErrMixedParams, i+1)
+			}
+			values = make(map[string]any, len(params))
+		}
+		if err := addNamedValue(values, arg.Name, arg.Value); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// addNamedValue adds value to values under name, without its prefix.
+func addNamedValue(values map[string]any, name string, value any) error {
+	if name != "" && strings.ContainsRune(":@$", rune(name[0])) {
+		name = name[1:]
+	}
+	if name == "" {
+		return fmt.Errorf("empty parameter name")
+	}
+	if _, ok := values[name]; ok {
+		return fmt.Errorf("named parameter %q given more than once", name)
+	}
+	values[name] = value
+	return nil
 }
 
 // Query executes a SQL query on the specified database and returns the results.
 // Each row is returned as a map[string]any, where the key is the column name.
 // Parameterized queries are supported to prevent SQL injection.
 //
-// Returns a [QueryResult] containing the query results and metadata.
+// If the result has several columns with the same name, only the last of them
+// is present in each map, unless the client was created with
+// [WithDisambiguatedColumns]. [Client.RawQuery] always returns every column.
+//
+// Returns a [QueryResult] containing the query results and metadata. Its
+// Columns field lists the column names in the order of the query. If the API
+// returns no result sets, the returned QueryResult is empty.
+//
+// If ctx is canceled or its deadline passes before the response arrives, the
+// HTTP request is abandoned and an error wrapping [ErrCanceled] is returned.
+// The query itself cannot be stopped, since D1 has no API to abort it, and may
+// still complete on the server.
 func (c *Client) Query(ctx context.Context, databaseID, sql string, params ...any) (*QueryResult, error) {
-	p2 := convertTypes(params)
-	body := map[string]any{
-		"sql":    sql,
-		"params": convertTypes(p2),
-	}
-	var result []QueryResult
-	err := c.sendRequest(ctx, http.MethodPost, fmt.Sprintf("/database/%s/query", databaseID), body, &result, nil)
+	// The raw API is used so that the column order of the query is known.
+	raw, err := c.RawQuery(ctx, databaseID, sql, params...)
 	if err != nil {
-		return nil, convertSQLiteError(err, sql, p2)
+		return nil, err
 	}
-	return &result[0], nil
+	result := QueryResult{Success: true}
+	if len(raw) > 0 {
+		result = raw[0].toQueryResult(c.disambiguateCols)
+	}
+	// Otherwise, the query had no statements, such as one holding only a
+	// comment, and produced no result sets.
+	if err := c.transformResult(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
 // RawQuery executes a SQL query and returns results in raw format. Returns a
@@ -91,15 +326,478 @@ func (c *Client) Query(ctx context.Context, databaseID, sql string, params ...an
 //	    fmt.Printf("User: ID=%v, Name=%v\n", row[0], row[1])
 //	}
 func (c *Client) RawQuery(ctx context.Context, databaseID, sql string, params ...any) ([]RawQueryResult, error) {
-	p2 := convertTypes(params)
+	stmt, err := c.prepareQuery(ctx, sql, params)
+	if err != nil {
+		return nil, err
+	}
+	sentSQL, wrapped := c.sentSQL(ctx, stmt.SQL)
+	if err := checkQuerySize(sentSQL); err != nil {
+		return nil, err
+	}
 	body := map[string]any{
-		"sql":    sql,
-		"params": p2,
+		"sql":    sentSQL,
+		"params": stmt.Params,
 	}
 	var result []RawQueryResult
-	err := c.sendRequest(ctx, http.MethodPost, fmt.Sprintf("/database/%s/raw", databaseID), body, &result, nil)
+	err = c.sendRequest(ctx, http.MethodPost, fmt.Sprintf("/database/%s/raw", databaseID), body, &result, nil)
 	if err != nil {
-		return nil, convertSQLiteError(err, sql, p2)
+		return nil, c.queryError(err, stmt.SQL, stmt.Params)
+	}
+	if wrapped && len(result) >= 2 {
+		result = result[1 : len(result)-1]
+	}
+	if c.maxResultSets > 0 && len(result) > c.maxResultSets {
+		return nil, fmt.Errorf("%w: query returned %d, maximum is %d", ErrTooManyResultSets, len(result), c.maxResultSets)
+	}
+	if err := c.checkResults(databaseID, result, func(int) Statement { return stmt }); err != nil {
+		return nil, err
 	}
 	return result, nil
 }
+
+// prepareQuery binds any named parameters in params, converts their types, and
+// checks the query against the client's limits. It returns the query and
+// parameters to send.
+func (c *Client) prepareQuery(ctx context.Context, sql string, params []any) (Statement, error) {
+	if err := c.checkAllowlist(sql); err != nil {
+		return Statement{}, err
+	}
+	sql, params, err := bindNamed(sql, params)
+	if err != nil {
+		return Statement{}, err
+	}
+	p2, err := convertTypes(params)
+	if err != nil {
+		return Statement{}, err
+	}
+	if err := c.checkUTF8(sql, p2); err != nil {
+		return Statement{}, err
+	}
+	if err := c.checkParamCount(sql, p2); err != nil {
+		return Statement{}, err
+	}
+	if err := c.checkStatementCount(sql); err != nil {
+		return Statement{}, err
+	}
+	c.checkNPlusOne(ctx, sql)
+	return Statement{SQL: sql, Params: p2}, nil
+}
+
+// checkResults applies the client's transformers to the results of a query on
+// databaseID, checks each result against the client's limits, and reports slow
+// queries. query returns the statement that produced the result at an index.
+func (c *Client) checkResults(databaseID string, results []RawQueryResult, query func(i int) Statement) error {
+	if err := c.transformRaw(results); err != nil {
+		return err
+	}
+	for i := range results {
+		if results[i].Meta.ChangedDB {
+			c.detailsCache.invalidate(databaseID)
+		}
+		if err := c.checkResultRows(len(results[i].Results.Rows)); err != nil {
+			return err
+		}
+		stmt := query(i)
+		c.checkSlowQuery(stmt.SQL, stmt.Params, results[i].Meta)
+	}
+	return nil
+}
+
+// queryRawBytes sends a query to the raw API, as given, and returns the body of
+// the response, along with any error. See [Handle.QueryRawBytes].
+func (c *Client) queryRawBytes(ctx context.Context, databaseID, sql string, params ...any) ([]byte, error) {
+	if err := c.checkAllowlist(sql); err != nil {
+		return nil, err
+	}
+	sql, params, err := bindNamed(sql, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkQuerySize(sql); err != nil {
+		return nil, err
+	}
+	p2, err := convertTypes(params)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]any{
+		"sql":    sql,
+		"params": p2,
+	}
+	var info responseInfo
+	err = c.sendRequest(ctx, http.MethodPost, fmt.Sprintf("/database/%s/raw", databaseID), body, nil, &info)
+	if err != nil {
+		return info.body, c.queryError(err, sql, p2)
+	}
+	return info.body, nil
+}
+
+// toQueryResult converts r into a [QueryResult], with each row as a map from
+// column name to value. If disambiguate is true, duplicate column names are
+// disambiguated; otherwise the last of the columns sharing a name is kept, and
+// the name appears once in the result's Columns, at its first position.
+func (r RawQueryResult) toQueryResult(disambiguate bool) QueryResult {
+	cols := r.Results.Columns
+	if disambiguate {
+		cols = disambiguateColumns(cols)
+	}
+	rows := make([]map[string]any, len(r.Results.Rows))
+	for i, row := range r.Results.Rows {
+		m := make(map[string]any, len(cols))
+		for j, v := range row {
+			if j < len(cols) {
+				m[cols[j]] = v
+			}
+		}
+		rows[i] = m
+	}
+	seen := make(map[string]bool, len(cols))
+	columns := make([]string, 0, len(cols))
+	for _, col := range cols {
+		if !seen[col] {
+			seen[col] = true
+			columns = append(columns, col)
+		}
+	}
+	return QueryResult{Meta: r.Meta, Columns: columns, Results: rows, Success: r.Success}
+}
+
+// Columnar returns the values of r by column, as a map from each column name to
+// a slice holding the column's value for each row, in row order. This layout
+// suits analytics tools that consume columnar data. Duplicate column names are
+// disambiguated as with [WithDisambiguatedColumns]. Every slice has one element
+// per row, even if the result has no rows.
+func (r RawQueryResult) Columnar() map[string][]any {
+	cols := disambiguateColumns(r.Results.Columns)
+	columnar := make(map[string][]any, len(cols))
+	for j, col := range cols {
+		values := make([]any, len(r.Results.Rows))
+		for i, row := range r.Results.Rows {
+			if j < len(row) {
+				values[i] = row[j]
+			}
+		}
+		columnar[col] = values
+	}
+	return columnar
+}
+
+// disambiguateColumns returns cols with each repeated column name given a
+// numeric suffix, starting from _2, that does not clash with any other column.
+// If cols has no duplicates, it is returned unchanged.
+func disambiguateColumns(cols []string) []string {
+	used := make(map[string]bool, len(cols))
+	for _, col := range cols {
+		used[col] = true
+	}
+	if len(used) == len(cols) {
+		return cols
+	}
+
+	out := make([]string, len(cols))
+	seen := make(map[string]bool, len(cols))
+	for i, col := range cols {
+		name := col
+		for n := 2; seen[name] || name != col && used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", col, n)
+		}
+		seen[name] = true
+		out[i] = name
+	}
+	return out
+}
+
+// sentSQL returns sql as it is sent by [Client.RawQuery] with ctx, with the
+// LIMIT clauses of the client's [WithDefaultLimit] and [WithMaxResultRows]
+// appended, and wrapped in a transaction by [WithImplicitTransactions], and
+// reports whether it was wrapped.
+func (c *Client) sentSQL(ctx context.Context, sql string) (string, bool) {
+	return c.implicitTransaction(c.limitRows(c.defaultLimit(ctx, sql)))
+}
+
+// querySizeOverhead returns the number of bytes that [Client.RawQuery] may add
+// to a query, other than the LIMIT clauses of [WithDefaultLimit], which depend
+// on its statements. Callers that fill queries up to [MaxQuerySize] reserve
+// this many bytes.
+func (c *Client) querySizeOverhead() int {
+	n := 0
+	if c.implicitTx {
+		n += len(implicitBegin) + len(implicitCommit)
+	}
+	if c.maxResultRows > 0 {
+		n += len(" LIMIT ") + len(strconv.Itoa(c.maxResultRows+1))
+	}
+	return n
+}
+
+// implicitBegin and implicitCommit are the statements added around a query by
+// wrapInTransaction.
+const (
+	implicitBegin  = "BEGIN TRANSACTION;\n"
+	implicitCommit = ";\nCOMMIT;"
+)
+
+// implicitTransaction returns sql wrapped in a transaction if the client was
+// created with [WithImplicitTransactions] and sql needs wrapping, and reports
+// whether it was wrapped.
+func (c *Client) implicitTransaction(sql string) (string, bool) {
+	if !c.implicitTx {
+		return sql, false
+	}
+	return wrapInTransaction(sql)
+}
+
+// wrapInTransaction returns sql wrapped in BEGIN TRANSACTION and COMMIT
+// statements, and true, if sql contains a write statement and no transaction
+// control statements. Otherwise, it returns sql unchanged, and false.
+func wrapInTransaction(sql string) (string, bool) {
+	hasWrite := false
+	for _, stmt := range splitStatements(tokenizeSQL(sql)) {
+		if isTransactionStatement(stmt) {
+			return sql, false
+		}
+		hasWrite = hasWrite || isWriteStatement(stmt)
+	}
+	if !hasWrite {
+		return sql, false
+	}
+	return implicitBegin + strings.TrimRight(strings.TrimSpace(sql), ";") + implicitCommit, true
+}
+
+// limitRows returns sql with a LIMIT clause appended, if the client was created
+// with [WithMaxResultRows] and sql is a single query without one. The limit is
+// one more than the maximum, so that exceeding the maximum can be detected.
+func (c *Client) limitRows(sql string) string {
+	if c.maxResultRows <= 0 {
+		return sql
+	}
+	return appendLimit(sql, c.maxResultRows+1)
+}
+
+// checkStatementCount returns an error wrapping [ErrTooManyResultSets] if the
+// client was created with [WithMaxResultSets] and sql has more statements than
+// the maximum.
+func (c *Client) checkStatementCount(sql string) error {
+	if c.maxResultSets <= 0 {
+		return nil
+	}
+	if n := len(splitStatements(tokenizeSQL(sql))); n > c.maxResultSets {
+		return fmt.Errorf("%w: query has %d statements, maximum is %d", ErrTooManyResultSets, n, c.maxResultSets)
+	}
+	return nil
+}
+
+// checkResultRows returns a [TooManyRowsError] if the client was created with
+// [WithMaxResultRows] and n exceeds the maximum.
+func (c *Client) checkResultRows(n int) error {
+	if c.maxResultRows > 0 && n > c.maxResultRows {
+		return &TooManyRowsError{MaxRows: c.maxResultRows}
+	}
+	return nil
+}
+
+// appendLimit returns sql with " LIMIT n" appended if it consists of a single
+// read-only statement that does not already have a LIMIT clause outside of any
+// parentheses. Otherwise, it returns sql unchanged.
+func appendLimit(sql string, n int) string {
+	statements := splitStatements(tokenizeSQL(sql))
+	if len(statements) != 1 {
+		return sql
+	}
+	return appendLimits(sql, statements, n)
+}
+
+// appendLimits returns sql, whose statements are given, with " LIMIT n"
+// appended to each SELECT, WITH, or VALUES statement that does not write to
+// the database or already have a LIMIT clause outside of any parentheses.
+// LIMIT clauses of subqueries are not affected.
+func appendLimits(sql string, statements [][]sqlToken, n int) string {
+	limit := " LIMIT " + strconv.Itoa(n)
+	for _, stmt := range slices.Backward(statements) {
+		if end, ok := limitPosition(stmt); ok {
+			sql = sql[:end] + limit + sql[end:]
+		}
+	}
+	return sql
+}
+
+// limitPosition returns the offset at which a LIMIT clause can be appended to
+// stmt, and true, if it is a read-only query without a LIMIT clause outside of
+// any parentheses.
+func limitPosition(stmt []sqlToken) (int, bool) {
+	if isWriteStatement(stmt) {
+		return 0, false
+	}
+	if first := stmt[0]; !first.is("SELECT") && !first.is("WITH") && !first.is("VALUES") {
+		return 0, false
+	}
+
+	depth := 0
+	for _, t := range stmt {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case depth == 0 && t.is("LIMIT"):
+			return 0, false
+		}
+	}
+	last := stmt[len(stmt)-1]
+	return last.pos + len(last.text), true
+}
+
+// noDefaultLimitKey is the context key set by [WithoutDefaultLimit].
+type noDefaultLimitKey struct{}
+
+// WithoutDefaultLimit returns a copy of ctx whose queries are sent without the
+// LIMIT clause added by [WithDefaultLimit], for queries that are known to need
+// every row.
+func WithoutDefaultLimit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noDefaultLimitKey{}, true)
+}
+
+// defaultLimit returns sql with the client's default LIMIT clause appended to
+// its unbounded queries, if it was created with [WithDefaultLimit] and ctx was
+// not returned by [WithoutDefaultLimit].
+func (c *Client) defaultLimit(ctx context.Context, sql string) string {
+	if c.defaultLimitRows <= 0 || ctx.Value(noDefaultLimitKey{}) != nil {
+		return sql
+	}
+	return appendLimits(sql, splitStatements(tokenizeSQL(sql)), c.defaultLimitRows)
+}
+
+// transformRaw applies the client's column transformers to the values in
+// results, and then its raw result transformers to each result, in place.
+func (c *Client) transformRaw(results []RawQueryResult) error {
+	if err := c.transformColumns(results); err != nil {
+		return err
+	}
+	for i := range results {
+		for _, fn := range c.rawTransformers {
+			if err := fn(&results[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// transformColumns applies the client's column transformers to the values in
+// results, in place.
+func (c *Client) transformColumns(results []RawQueryResult) error {
+	if len(c.columnTransformers) == 0 {
+		return nil
+	}
+	for _, rs := range results {
+		for j, col := range rs.Results.Columns {
+			if c.columnTransformers[col] == nil {
+				continue
+			}
+			for _, row := range rs.Results.Rows {
+				if j >= len(row) {
+					continue
+				}
+				tv, err := c.transformValue(col, row[j])
+				if err != nil {
+					return err
+				}
+				row[j] = tv
+			}
+		}
+	}
+	return nil
+}
+
+// transformResult applies the client's result transformers to result, in
+// place.
+func (c *Client) transformResult(result *QueryResult) error {
+	for _, fn := range c.resultTransformers {
+		if err := fn(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transformValue applies the transformer for col, if any, to a non-nil v.
+func (c *Client) transformValue(col string, v any) (any, error) {
+	fn := c.columnTransformers[col]
+	if fn == nil || v == nil {
+		return v, nil
+	}
+	tv, err := fn(v)
+	if err != nil {
+		return nil, fmt.Errorf("transforming column %q: %w", col, err)
+	}
+	return tv, nil
+}
+
+// queryError converts an error from executing sql with bindings into a
+// [SQLiteError] where appropriate, adding binding hints if they are enabled.
+func (c *Client) queryError(err error, sql string, bindings []any) error {
+	err = convertSQLiteError(err, sql, bindings)
+	if sqliteErr, ok := err.(*SQLiteError); ok && c.bindingHints {
+		sqliteErr.addBindingHints()
+	}
+	return err
+}
+
+// checkSlowQuery calls the client's slow query handler if meta reports an
+// execution time above the configured threshold.
+func (c *Client) checkSlowQuery(sql string, params []any, meta QueryMeta) {
+	if c.slowQueryHandler != nil && c.slowQueryThreshold > 0 && meta.elapsed() > c.slowQueryThreshold {
+		c.slowQueryHandler(sql, params, meta)
+	}
+}
+
+// checkQuerySize returns a QueryTooLargeError if sql, as it is to be sent, is
+// larger than D1 accepts. The size is counted in bytes.
+func checkQuerySize(sql string) error {
+	if len(sql) > MaxQuerySize {
+		return &QueryTooLargeError{Size: len(sql), Max: MaxQuerySize}
+	}
+	return nil
+}
+
+// paramLimit returns the maximum number of parameters in a query.
+func (c *Client) paramLimit() int {
+	if c.maxQueryParams > 0 {
+		return c.maxQueryParams
+	}
+	return MaxQueryParams
+}
+
+// checkParamCount returns a TooManyParamsError if sql binds more parameters
+// than the client's limit. The count is the larger of the number of values in
+// params and the highest parameter number used by a placeholder in sql.
+func (c *Client) checkParamCount(sql string, params []any) error {
+	limit := c.paramLimit()
+	count := len(params)
+	if count <= limit {
+		for _, n := range paramNumbers(tokenizeSQL(sql)) {
+			count = max(count, n)
+		}
+	}
+	if count > limit {
+		return &TooManyParamsError{Count: count, Max: limit}
+	}
+	return nil
+}
+
+// checkUTF8 returns an error if the client rejects invalid UTF-8 and sql or one
+// of the string values in params is not valid UTF-8.
+func (c *Client) checkUTF8(sql string, params []any) error {
+	if c.utf8Handling != UTF8Reject {
+		return nil
+	}
+	if !utf8.ValidString(sql) {
+		return fmt.Errorf("query: %w", ErrInvalidUTF8)
+	}
+	for i, p := range params {
+		if s, ok := p.(string); ok && !utf8.ValidString(s) {
+			return fmt.Errorf("parameter %d: %w", i+1, ErrInvalidUTF8)
+		}
+	}
+	return nil
+}
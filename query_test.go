@@ -0,0 +1,662 @@
+package cfd1
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowQueryHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration float64
+		expected bool
+	}{
+		{"Slow", 150, true},
+		{"Fast", 50, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				rs := rawResult([]string{"x"}, []any{1})
+				rs.Meta.Duration = tt.duration
+				writeAPIResult(w, []RawQueryResult{rs}, nil)
+			},
+				WithSlowQueryThreshold(100*time.Millisecond),
+				WithSlowQueryHandler(func(sql string, params []any, meta QueryMeta) {
+					called = true
+					if sql != "SELECT ?" || len(params) != 1 || meta.Duration != tt.duration {
+						t.Errorf("unexpected handler arguments: %q %v %+v", sql, params, meta)
+					}
+				}),
+			)
+
+			_, err := client.RawQuery(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", "SELECT ?", 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if called != tt.expected {
+				t.Errorf("unexpected handler call: got %v, want %v", called, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUTF8Handling(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        UTF8Handling
+		params      []any
+		expectError bool
+	}{
+		{"Valid string", UTF8Reject, []any{"héllo"}, false},
+		{"Invalid string rejected", UTF8Reject, []any{1, "bad\xff"}, true},
+		{"Invalid string replaced", UTF8Replace, []any{"bad\xff"}, false},
+		{"Bytes not checked", UTF8Reject, []any{[]byte("bad\xff")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1})}, nil)
+			}, WithUTF8Handling(tt.mode))
+
+			_, err := client.RawQuery(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", "SELECT ?", tt.params...)
+			if (err != nil) != tt.expectError {
+				t.Errorf("unexpected error state: got %v, want error: %v", err, tt.expectError)
+			}
+			if tt.expectError && !errors.Is(err, ErrInvalidUTF8) {
+				t.Errorf("expected ErrInvalidUTF8, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDisambiguateColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		cols     []string
+		expected []string
+	}{
+		{"Unique", []string{"id", "name"}, []string{"id", "name"}},
+		{"Duplicate", []string{"id", "name", "id"}, []string{"id", "name", "id_2"}},
+		{"Triplicate", []string{"id", "id", "id"}, []string{"id", "id_2", "id_3"}},
+		{"Clash", []string{"id", "id", "id_2"}, []string{"id", "id_3", "id_2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := disambiguateColumns(tt.cols)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQueryDisambiguatedColumns(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/raw") {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"id", "id"}, []any{1.0, 2.0})}, nil)
+	}, WithDisambiguatedColumns())
+
+	result, err := client.Query(context.Background(), "db", "SELECT u.id, o.id FROM users u JOIN orders o ON o.user_id = u.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []map[string]any{{"id": int64(1), "id_2": int64(2)}}
+	if !reflect.DeepEqual(result.Results, want) {
+		t.Errorf("got %v, want %v", result.Results, want)
+	}
+}
+
+func TestQueryColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []ClientOption
+		cols    []string
+		row     []any
+		columns []string
+		results map[string]any
+	}{
+		{"Order", nil, []string{"z", "a", "m"}, []any{1, 2.5, 3},
+			[]string{"z", "a", "m"}, map[string]any{"z": int64(1), "a": 2.5, "m": int64(3)}},
+		{"Duplicate", nil, []string{"id", "name", "id"}, []any{1, "x", 2},
+			[]string{"id", "name"}, map[string]any{"id": int64(2), "name": "x"}},
+		{"Disambiguated", []ClientOption{WithDisambiguatedColumns()}, []string{"id", "name", "id"}, []any{1, "x", 2},
+			[]string{"id", "name", "id_2"}, map[string]any{"id": int64(1), "name": "x", "id_2": int64(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeAPIResult(w, []RawQueryResult{rawResult(tt.cols, tt.row)}, nil)
+			}, tt.opts...)
+
+			result, err := client.Query(context.Background(), "db", "SELECT ...")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Columns, tt.columns) {
+				t.Errorf("got columns %v, want %v", result.Columns, tt.columns)
+			}
+			if len(result.Results) != 1 || !reflect.DeepEqual(result.Results[0], tt.results) {
+				t.Errorf("got results %v, want %v", result.Results, tt.results)
+			}
+		})
+	}
+}
+
+func TestWrapInTransaction(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+		wrapped  bool
+	}{
+		{"Write", "INSERT INTO t VALUES (1);", "BEGIN TRANSACTION;\nINSERT INTO t VALUES (1);\nCOMMIT;", true},
+		{"Read", "SELECT * FROM t", "SELECT * FROM t", false},
+		{"Mixed", "SELECT 1; DELETE FROM t", "BEGIN TRANSACTION;\nSELECT 1; DELETE FROM t;\nCOMMIT;", true},
+		{"Explicit", "BEGIN; DELETE FROM t; COMMIT;", "BEGIN; DELETE FROM t; COMMIT;", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, wrapped := wrapInTransaction(tt.sql)
+			if got != tt.expected || wrapped != tt.wrapped {
+				t.Errorf("got (%q, %v), want (%q, %v)", got, wrapped, tt.expected, tt.wrapped)
+			}
+		})
+	}
+}
+
+func TestImplicitTransactions(t *testing.T) {
+	var req rawQueryRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		writeAPIResult(w, []QueryResult{
+			{Success: true},
+			{Success: true, Meta: QueryMeta{Changes: 1, RowsWritten: 1}},
+			{Success: true},
+		}, nil)
+	}, WithImplicitTransactions())
+
+	result, err := client.Query(context.Background(), "db", "INSERT INTO t VALUES (?)", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(req.SQL, "BEGIN TRANSACTION;") {
+		t.Errorf("query was not wrapped: %q", req.SQL)
+	}
+	if result.Meta.Changes != 1 {
+		t.Errorf("unexpected result: got %+v", result.Meta)
+	}
+}
+
+func TestAppendLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{"Select", "SELECT * FROM t", "SELECT * FROM t LIMIT 11"},
+		{"Trailing semicolon", "SELECT * FROM t;", "SELECT * FROM t LIMIT 11;"},
+		{"Existing limit", "SELECT * FROM t LIMIT 5", "SELECT * FROM t LIMIT 5"},
+		{"Subquery limit", "SELECT * FROM (SELECT * FROM t LIMIT 5)", "SELECT * FROM (SELECT * FROM t LIMIT 5) LIMIT 11"},
+		{"Multiple statements", "SELECT 1; SELECT 2", "SELECT 1; SELECT 2"},
+		{"Write", "INSERT INTO t VALUES (1)", "INSERT INTO t VALUES (1)"},
+		{"Pragma", "PRAGMA table_info(t)", "PRAGMA table_info(t)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendLimit(tt.sql, 11); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDefaultLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		sql      string
+		expected string
+	}{
+		{"Select", context.Background(), "SELECT * FROM t", "SELECT * FROM t LIMIT 50"},
+		{"Existing limit", context.Background(), "SELECT * FROM t LIMIT 5", "SELECT * FROM t LIMIT 5"},
+		{"Subquery limit", context.Background(), "SELECT * FROM (SELECT * FROM t LIMIT 5) WHERE x IN (SELECT x FROM u)",
+			"SELECT * FROM (SELECT * FROM t LIMIT 5) WHERE x IN (SELECT x FROM u) LIMIT 50"},
+		{"Multiple statements", context.Background(), "SELECT 1; UPDATE t SET x = 1; SELECT 2 LIMIT 1; SELECT 3;",
+			"SELECT 1 LIMIT 50; UPDATE t SET x = 1; SELECT 2 LIMIT 1; SELECT 3 LIMIT 50;"},
+		{"Write", context.Background(), "INSERT INTO t SELECT * FROM u", "INSERT INTO t SELECT * FROM u"},
+		{"Bypassed", WithoutDefaultLimit(context.Background()), "SELECT * FROM t", "SELECT * FROM t"},
+	}
+	var req rawQueryRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"})}, nil)
+	}, WithDefaultLimit(50))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.RawQuery(tt.ctx, "db", tt.sql); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if req.SQL != tt.expected {
+				t.Errorf("got %q, want %q", req.SQL, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMaxResultRows(t *testing.T) {
+	var req rawQueryRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1}, []any{2}, []any{3})}, nil)
+	}, WithMaxResultRows(2))
+
+	_, err := client.RawQuery(context.Background(), "db", "SELECT x FROM t")
+	if !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("expected ErrTooManyRows, got %v", err)
+	}
+	if req.SQL != "SELECT x FROM t LIMIT 3" {
+		t.Errorf("unexpected SQL: %q", req.SQL)
+	}
+}
+
+func TestColumnTransformer(t *testing.T) {
+	statusNames := map[int64]string{0: "pending", 1: "active"}
+	transformer := WithColumnTransformer("status", func(v any) (any, error) {
+		name, ok := statusNames[v.(int64)]
+		if !ok {
+			return nil, fmt.Errorf("unknown status %v", v)
+		}
+		return name, nil
+	})
+
+	t.Run("Raw", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeAPIResult(w, []RawQueryResult{rawResult([]string{"id", "status"},
+				[]any{1.0, 1.0}, []any{2.0, nil})}, nil)
+		}, transformer)
+		h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+		var row struct {
+			ID     int    `db:"id"`
+			Status string `db:"status"`
+		}
+		if err := h.QueryRow(context.Background(), "SELECT id, status FROM t").ScanStruct(&row); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if row.Status != "active" {
+			t.Errorf("unexpected status: %q", row.Status)
+		}
+	})
+
+	t.Run("Maps", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeAPIResult(w, []RawQueryResult{rawResult([]string{"id", "status"},
+				[]any{1.0, 0.0},
+				[]any{2.0, 9.0},
+			)}, nil)
+		}, transformer)
+
+		_, err := client.Query(context.Background(), "db", "SELECT id, status FROM t")
+		if err == nil || !strings.Contains(err.Error(), "unknown status 9") {
+			t.Errorf("expected transformer error, got %v", err)
+		}
+	})
+}
+
+func TestResultTransformers(t *testing.T) {
+	var order []string
+	redact := WithResultTransformer(func(r *QueryResult) error {
+		order = append(order, "redact")
+		r.Columns = slices.DeleteFunc(r.Columns, func(c string) bool { return c == "secret" })
+		for _, row := range r.Results {
+			delete(row, "secret")
+		}
+		return nil
+	})
+	rename := WithResultTransformer(func(r *QueryResult) error {
+		order = append(order, "rename")
+		for i, c := range r.Columns {
+			if c == "id" {
+				r.Columns[i] = "ID"
+			}
+		}
+		for _, row := range r.Results {
+			row["ID"] = row["id"]
+			delete(row, "id")
+		}
+		return nil
+	})
+	raw := WithRawResultTransformer(func(r *RawQueryResult) error {
+		order = append(order, "raw")
+		for _, row := range r.Results.Rows {
+			row[1] = "[redacted]"
+		}
+		return nil
+	})
+	fail := WithResultTransformer(func(r *QueryResult) error {
+		return errors.New("transformer failed")
+	})
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"id", "secret"}, []any{1, "hunter2"})}, nil)
+	}
+
+	client := newTestClient(t, handler, redact, rename, raw)
+	result, err := client.Query(context.Background(), "db", "SELECT id, secret FROM users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"raw", "redact", "rename"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("transformers ran in order %v, want %v", order, want)
+	}
+	if want := []string{"ID"}; !reflect.DeepEqual(result.Columns, want) {
+		t.Errorf("got columns %v, want %v", result.Columns, want)
+	}
+	if want := []map[string]any{{"ID": int64(1)}}; !reflect.DeepEqual(result.Results, want) {
+		t.Errorf("got results %v, want %v", result.Results, want)
+	}
+
+	// Only the raw transformer applies to raw results
+	order = nil
+	rawResults, err := client.RawQuery(context.Background(), "db", "SELECT id, secret FROM users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rawResults[0].Results.Rows[0][1]; got != "[redacted]" || len(order) != 1 {
+		t.Errorf("got %v after transformers %v", got, order)
+	}
+
+	client = newTestClient(t, handler, fail)
+	if _, err := client.Query(context.Background(), "db", "SELECT id, secret FROM users"); err == nil {
+		t.Errorf("expected transformer error")
+	}
+}
+
+func TestColumnar(t *testing.T) {
+	rs := rawResult([]string{"id", "name", "id"},
+		[]any{1.0, "alice", 10.0},
+		[]any{2.0, nil, 20.0},
+	)
+	want := map[string][]any{
+		"id":   {1.0, 2.0},
+		"name": {"alice", nil},
+		"id_2": {10.0, 20.0},
+	}
+	if got := rs.Columnar(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMaxResultSets(t *testing.T) {
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		rs := rawResult([]string{"x"}, []any{1.0})
+		writeAPIResult(w, []RawQueryResult{rs, rs, rs}, nil)
+	}, WithMaxResultSets(2))
+
+	_, err := client.RawQuery(context.Background(), "db", "SELECT 1; SELECT 2; SELECT 3")
+	if !errors.Is(err, ErrTooManyResultSets) || requests != 0 {
+		t.Errorf("expected ErrTooManyResultSets without a request, got %v after %d requests", err, requests)
+	}
+
+	// The response is checked even if the statement count was within the limit
+	_, err = client.RawQuery(context.Background(), "db", "SELECT 1")
+	if !errors.Is(err, ErrTooManyResultSets) || requests != 1 {
+		t.Errorf("expected ErrTooManyResultSets after 1 request, got %v after %d requests", err, requests)
+	}
+}
+
+// testValuer is a driver.Valuer that stores itself as TEXT.
+type testValuer struct {
+	s   string
+	err error
+}
+
+func (v testValuer) Value() (driver.Value, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	return "valuer:" + v.s, nil
+}
+
+// testTimeValuer is a driver.Valuer whose value is further converted.
+type testTimeValuer struct{ t time.Time }
+
+func (v testTimeValuer) Value() (driver.Value, error) { return v.t, nil }
+
+func TestConvertTypesValuer(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		params   []any
+		expected []any
+		wantErr  bool
+	}{
+		{"Valuer", []any{testValuer{s: "x"}}, []any{"valuer:x"}, false},
+		{"Pointer to Valuer", []any{&testValuer{s: "y"}}, []any{"valuer:y"}, false},
+		{"Nil pointer", []any{(*testValuer)(nil)}, []any{nil}, false},
+		{"Value converted", []any{testTimeValuer{ts}}, []any{int(ts.Unix())}, false},
+		{"Value error", []any{1, testValuer{err: errors.New("boom")}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertTypes(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertTypes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("convertTypes() = %#v, want %#v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMaxQuerySize(t *testing.T) {
+	// query returns a query of n bytes, whose string literal holds multibyte
+	// characters, so that it has fewer runes than bytes.
+	query := func(n int) string {
+		const prefix, suffix = "SELECT '", "'"
+		body := n - len(prefix) - len(suffix)
+		return prefix + strings.Repeat("é", body/2) + strings.Repeat("x", body%2) + suffix
+	}
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"})}, nil)
+	})
+	ctx := context.Background()
+	h, _ := client.GetHandle(ctx, "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	if _, err := client.RawQuery(ctx, "db", query(MaxQuerySize)); err != nil {
+		t.Errorf("unexpected error at the limit: %v", err)
+	}
+	calls := map[string]func(sql string) error{
+		"Query":         func(sql string) error { _, err := client.Query(ctx, "db", sql); return err },
+		"RawQuery":      func(sql string) error { _, err := client.RawQuery(ctx, "db", sql); return err },
+		"Batch":         func(sql string) error { _, err := h.Batch(ctx, []Statement{{SQL: sql}}); return err },
+		"QueryRawBytes": func(sql string) error { _, err := h.QueryRawBytes(ctx, sql); return err },
+	}
+	for name, call := range calls {
+		err := call(query(MaxQuerySize + 1))
+		var tooLarge *QueryTooLargeError
+		if !errors.Is(err, ErrQueryTooLarge) || !errors.As(err, &tooLarge) || tooLarge.Size != MaxQuerySize+1 {
+			t.Errorf("%s: expected QueryTooLargeError with size %d, got %v", name, MaxQuerySize+1, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("oversized queries were sent: got %d requests, want 1", requests)
+	}
+}
+
+func TestMaxQueryParams(t *testing.T) {
+	params := func(n int) []any {
+		p := make([]any, n)
+		for i := range p {
+			p[i] = i
+		}
+		return p
+	}
+	tests := []struct {
+		name    string
+		opts    []ClientOption
+		sql     string
+		params  []any
+		wantErr int // the count reported by the error, or 0 for no error
+	}{
+		{"At limit", nil, "SELECT ?", params(100), 0},
+		{"Over limit", nil, "SELECT ?", params(142), 142},
+		{"Numbered placeholder", nil, "SELECT ?101", params(1), 101},
+		{"Placeholder in string", nil, "SELECT '?101'", nil, 0},
+		{"Custom limit", []ClientOption{WithMaxQueryParams(10)}, "SELECT ?", params(11), 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"})}, nil)
+			}, tt.opts...)
+
+			_, err := client.RawQuery(context.Background(), "db", tt.sql, tt.params...)
+			if tt.wantErr == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var tmp *TooManyParamsError
+			if !errors.As(err, &tmp) || !errors.Is(err, ErrTooManyParams) || tmp.Count != tt.wantErr {
+				t.Fatalf("expected TooManyParamsError with count %d, got %v", tt.wantErr, err)
+			}
+			if requests != 0 {
+				t.Errorf("expected no requests, got %d", requests)
+			}
+			_, err = client.Query(context.Background(), "db", tt.sql, tt.params...)
+			if !errors.Is(err, ErrTooManyParams) {
+				t.Errorf("Query: expected ErrTooManyParams, got %v", err)
+			}
+		})
+	}
+
+	if got := (&TooManyParamsError{Count: 142, Max: 100}).Error(); !strings.HasPrefix(got, "142 params exceeds D1 limit of 100; consider chunking") {
+		t.Errorf("unexpected error message %q", got)
+	}
+}
+
+func TestRawQueryNumbers(t *testing.T) {
+	const big = 12345678901234567 // not representable as a float64
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"id", "price", "big", "huge"},
+			[]any{int64(big), 2.5, json.Number("1e3"), json.Number("18446744073709551616")},
+		)}, nil)
+	})
+
+	result, err := client.RawQuery(context.Background(), "db", "SELECT id, price, big, huge FROM t")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []any{int64(big), 2.5, 1000.0, 18446744073709551616.0}
+	if got := result[0].Results.Rows[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	var id string
+	var price, n, huge float64
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	if err := h.QueryRow(context.Background(), "SELECT id, price, big, huge FROM t").Scan(&id, &price, &n, &huge); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "12345678901234567" {
+		t.Errorf("got id %q, want %d", id, int64(big))
+	}
+}
+
+func TestQueryEmptyResult(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIResult(w, []RawQueryResult{}, nil)
+	})
+
+	result, err := client.Query(context.Background(), "db", "-- nothing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success || len(result.Results) != 0 || len(result.Columns) != 0 {
+		t.Errorf("expected an empty result, got %+v", result)
+	}
+
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	if err := h.Execute(context.Background(), "-- nothing"); err != nil {
+		t.Errorf("Execute: unexpected error: %v", err)
+	}
+}
+
+func TestNamedParams(t *testing.T) {
+	errAny := errors.New("any error")
+	tests := []struct {
+		name       string
+		sql        string
+		params     []any
+		wantSQL    string
+		wantParams []any
+		wantErr    error // nil for no error, or errAny for any error
+	}{
+		{"Named", "SELECT * FROM t WHERE a = :a AND b = @b", []any{Named("b", "x"), Named("a", 1)},
+			"SELECT * FROM t WHERE a = ?1 AND b = ?2", []any{1.0, "x"}, nil},
+		{"Map", "SELECT $id, :id", []any{map[string]any{"id": 7}},
+			"SELECT ?1, ?2", []any{7.0, 7.0}, nil},
+		{"Repeated name", "SELECT :id + :id", []any{Named(":id", 2)},
+			"SELECT ?1 + ?1", []any{2.0}, nil},
+		{"Converted value", "SELECT :flag", []any{Named("flag", true)},
+			"SELECT ?1", []any{1.0}, nil},
+		{"Name in string", "SELECT ':a', :a", []any{Named("a", "v")},
+			"SELECT ':a', ?1", []any{"v"}, nil},
+		{"Positional", "SELECT ?", []any{1}, "SELECT ?", []any{1.0}, nil},
+		{"Mixed params", "SELECT :a, ?", []any{Named("a", 1), 2}, "", nil, ErrMixedParams},
+		{"Positional first", "SELECT ?, :a", []any{1, Named("a", 2)}, "", nil, ErrMixedParams},
+		{"Positional placeholder", "SELECT :a, ?", []any{Named("a", 1)}, "", nil, ErrMixedParams},
+		{"Missing value", "SELECT :a, :b", []any{Named("a", 1)}, "", nil, errAny},
+		{"Unused value", "SELECT :a", []any{Named("a", 1), Named("b", 2)}, "", nil, errAny},
+		{"Duplicate value", "SELECT :a", []any{Named("a", 1), Named("@a", 2)}, "", nil, errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req rawQueryRequest
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&req)
+				writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"})}, nil)
+			})
+
+			_, err := client.Query(context.Background(), "db", tt.sql, tt.params...)
+			if tt.wantErr != nil {
+				if err == nil || (tt.wantErr != errAny && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				if req.SQL != "" {
+					t.Errorf("expected no request, got %q", req.SQL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if req.SQL != tt.wantSQL || !reflect.DeepEqual(req.Params, tt.wantParams) {
+				t.Errorf("sent %q %v, want %q %v", req.SQL, req.Params, tt.wantSQL, tt.wantParams)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package cfd1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// cassette is the on-disk format of requests recorded with [WithRecorder] and
+// served with [WithReplay].
+type cassette struct {
+	Interactions []interaction `json:"interactions"`
+}
+
+// interaction is a single recorded request and its response.
+type interaction struct {
+	Request struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Body   string `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body"`
+	} `json:"response"`
+}
+
+// WithRecorder records every request made by the client and the response it
+// received to the file at path, which can later be served with [WithReplay].
+// This allows tests to be run against the D1 API once and then repeated
+// offline. The file is created or truncated by the first request and rewritten
+// after each request, so it is complete even if the program exits early.
+//
+// Requests are recorded with their method, path, including any query string,
+// and body. Request headers, including the API token, are not recorded, but
+// request and response bodies are, so a recording can contain the account ID,
+// database IDs, queries, and their results. If the file cannot be written, the
+// request returns an error.
+func WithRecorder(path string) ClientOption {
+	return func(c *Client) {
+		transport := c.httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		httpClient := *c.httpClient
+		c.httpClient = &httpClient
+		c.httpClient.Transport = &recordTransport{transport: transport, path: path}
+	}
+}
+
+// WithReplay serves the client's requests from a file recorded with
+// [WithRecorder], without network access. Each request is matched against the
+// recorded requests by method, path, and body. When the same request was
+// recorded more than once, its responses are served in the order they were
+// recorded, so polling sequences replay faithfully. A request with no unused
+// matching recording returns an error. The file is read by the first request.
+//
+// Because the account ID and database IDs form part of each path, the client
+// must be created with the same account ID, and access the same databases, as
+// when the requests were recorded; the API token and endpoint may differ.
+func WithReplay(path string) ClientOption {
+	return func(c *Client) {
+		httpClient := *c.httpClient
+		c.httpClient = &httpClient
+		c.httpClient.Transport = &replayTransport{path: path}
+	}
+}
+
+// recordTransport is an http.RoundTripper that records requests and responses
+// to a file.
+type recordTransport struct {
+	transport http.RoundTripper
+	path      string
+
+	mu       sync.Mutex
+	cassette cassette
+}
+
+// RoundTrip executes an HTTP request and records it along with its response.
+func (r *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		reqBody, _ = io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewBuffer(reqBody))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewBuffer(respBody))
+
+	var in interaction
+	in.Request.Method = req.Method
+	in.Request.Path = req.URL.RequestURI()
+	in.Request.Body = string(reqBody)
+	in.Response.StatusCode = resp.StatusCode
+	in.Response.Header = resp.Header
+	in.Response.Body = string(respBody)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return nil, fmt.Errorf("cfd1: writing recording: %w", err)
+	}
+	return resp, nil
+}
+
+// replayTransport is an http.RoundTripper that serves responses from a file
+// written by recordTransport.
+type replayTransport struct {
+	path string
+
+	mu      sync.Mutex
+	loaded  bool
+	loadErr error
+	unused  []interaction
+}
+
+// RoundTrip returns the recorded response for an HTTP request.
+func (r *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		reqBody, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded {
+		r.loaded = true
+		r.loadErr = r.load()
+	}
+	if r.loadErr != nil {
+		return nil, r.loadErr
+	}
+
+	path := req.URL.RequestURI()
+	for i, in := range r.unused {
+		if in.Request.Method != req.Method || in.Request.Path != path || in.Request.Body != string(reqBody) {
+			continue
+		}
+		r.unused = append(r.unused[:i], r.unused[i+1:]...)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewBufferString(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cfd1: no recorded response for %s %s", req.Method, path)
+}
+
+// load reads the recorded interactions from the file.
+func (r *replayTransport) load() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("cfd1: reading recording: %w", err)
+	}
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("cfd1: parsing recording %s: %w", r.path, err)
+	}
+	r.unused = c.Interactions
+	return nil
+}
@@ -0,0 +1,46 @@
+package cfd1
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	const dbID = "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f"
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	ctx := context.Background()
+
+	calls := 0
+	recorder := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"n"}, []any{float64(calls)})}, nil)
+	}, WithRecorder(path))
+	for range 2 {
+		if _, err := recorder.RawQuery(ctx, dbID, "SELECT n FROM counter"); err != nil {
+			t.Fatalf("RawQuery() error = %v", err)
+		}
+	}
+
+	// The replaying client uses an endpoint that does not exist, so any request
+	// reaching the network would fail.
+	replayer := NewClient("test-account", "other-token", WithEndpoint("http://127.0.0.1:1"), WithReplay(path))
+	for i := 1; i <= 2; i++ {
+		result, err := replayer.RawQuery(ctx, dbID, "SELECT n FROM counter")
+		if err != nil {
+			t.Fatalf("replay %d: RawQuery() error = %v", i, err)
+		}
+		if want := [][]any{{int64(i)}}; !reflect.DeepEqual(result[0].Results.Rows, want) {
+			t.Errorf("replay %d: rows = %v, want %v", i, result[0].Results.Rows, want)
+		}
+	}
+
+	if _, err := replayer.RawQuery(ctx, dbID, "SELECT n FROM counter"); err == nil {
+		t.Error("expected error when recorded responses are exhausted")
+	}
+	if _, err := replayer.RawQuery(ctx, dbID, "SELECT other"); err == nil {
+		t.Error("expected error for unrecorded request")
+	}
+}
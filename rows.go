@@ -1,8 +1,12 @@
 package cfd1
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -12,29 +16,39 @@ import (
 // Row is a single row of query results.
 type Row struct {
 	result   *RawQueryResult
-	fieldMap map[string]int
+	fieldMap map[string]structField
+	strict   bool // see WithStrictScan
 	err      error
 }
 
-// Rows is a collection of rows of query results.
+// Rows is a collection of rows of query results. Its Next, NextResultSet,
+// Scan, Columns, Close, and Err methods have the same signatures and behavior
+// as those of [sql.Rows], so it can be used with code written against that
+// subset of sql.Rows' methods. [Rows.ColumnTypes] returns a [ColumnType] with
+// the methods of [sql.ColumnType] that D1 can support.
 type Rows struct {
 	result     []RawQueryResult
 	rs         *RawQueryResult
 	current    int
 	currentSet int
-	fieldMap   map[string]int
+	fieldMap   map[string]structField
+	strict     bool // see WithStrictScan
 	err        error
+	closed     bool
 }
 
-func newRow(result *RawQueryResult, err error) *Row {
+// errRowsClosed is returned when scanning from a closed Rows.
+var errRowsClosed = errors.New("rows are closed")
+
+func newRow(result *RawQueryResult, strict bool, err error) *Row {
 	if err != nil {
 		return &Row{err: err}
 	}
 
-	return &Row{result: result}
+	return &Row{result: result, strict: strict}
 }
 
-func newRows(result []RawQueryResult, err error) *Rows {
+func newRows(result []RawQueryResult, strict bool, err error) *Rows {
 	if err != nil {
 		return &Rows{err: err}
 	}
@@ -42,6 +56,7 @@ func newRows(result []RawQueryResult, err error) *Rows {
 	ret := Rows{
 		current: -1,
 		result:  result,
+		strict:  strict,
 	}
 	if len(result) > 0 {
 		ret.rs = &result[0]
@@ -78,7 +93,7 @@ func (r *Row) Scan(dest ...interface{}) error {
 		if i >= len(dest) {
 			break
 		}
-		if err := assign(dest[i], col); err != nil {
+		if err := assignValue(dest[i], col, r.strict); err != nil {
 			return fmt.Errorf("column %d: %w", i, err)
 		}
 	}
@@ -89,7 +104,9 @@ func (r *Row) Scan(dest ...interface{}) error {
 // ScanStruct scans the current row into a struct. The struct fields are matched
 // to the column names in the result set. The struct fields can be tagged with
 // `db`, `sql`, or `json` to specify the column name. If no tag is present, the
-// field name is used.
+// field name is used. NULL values are scanned as the zero value of a field,
+// unless its tag has the notnull option, as in `db:"name,notnull"`, in which
+// case scanning NULL into it fails with an error.
 func (r *Row) ScanStruct(dest interface{}) error {
 	if r.Err() != nil {
 		return r.Err()
@@ -107,28 +124,49 @@ func (r *Row) ScanStruct(dest interface{}) error {
 	if r.fieldMap == nil {
 		r.fieldMap = createFieldMap(v.Type())
 	}
-	return scanStructWithMap(r.result.Results.Columns, r.result.Results.Rows[0], v, r.fieldMap)
+	return scanStructWithMap(r.result.Results.Columns, r.result.Results.Rows[0], v, r.fieldMap, r.strict)
 }
 
-// Err returns the error, if any, that was encountered during iteration.
+// ScanSlice scans every column of the current row into the slice that dest
+// points to, such as a *[]string or *[]int, which is resized to the number of
+// columns. Each value is converted to the slice's element type as with
+// [Row.Scan]. This suits queries whose number of columns is not known in
+// advance. An error is returned if a value cannot be converted.
+func (r *Row) ScanSlice(dest any) error {
+	if r.Err() != nil {
+		return r.Err()
+	}
+	return scanSlice(r.result.Results.Rows[0], dest, r.strict)
+}
+
+// Err returns the error, if any, that was encountered by the query or during
+// iteration. As with [sql.Rows], a query that returned no rows is not an error.
 func (r *Rows) Err() error {
 	if r == nil {
 		return sql.ErrNoRows
 	}
+	return r.err
+}
 
-	if r.err != nil {
-		return r.err
+// checkRow returns the error to report when scanning the current row: the
+// query's error, if any, or an error if r is closed or has no current row.
+func (r *Rows) checkRow() error {
+	if err := r.Err(); err != nil {
+		return err
 	}
-
-	if r.result == nil || r.currentSet >= len(r.result) || len(r.rs.Results.Rows) == 0 {
+	if r.closed {
+		return errRowsClosed
+	}
+	if r.rs == nil || r.current < 0 || r.current >= len(r.rs.Results.Rows) {
 		return sql.ErrNoRows
 	}
-
 	return nil
 }
 
+// Next advances to the next row of the current result set, returning false if
+// there are no more rows, or if the query returned no result sets.
 func (r *Rows) Next() bool {
-	if r.Err() != nil {
+	if r == nil || r.err != nil || r.closed || r.rs == nil {
 		return false
 	}
 
@@ -140,14 +178,18 @@ func (r *Rows) Next() bool {
 	return true
 }
 
+// NextSet advances to the next result set, returning false if there are no
+// more result sets. It may be called whether or not the current result set had
+// any rows.
 func (r *Rows) NextSet() bool {
-	if r.Err() != nil {
+	if r == nil || r.err != nil || r.closed || r.currentSet >= len(r.result) {
 		return false
 	}
 
 	r.current = -1
 	r.currentSet++
 	if r.currentSet >= len(r.result) {
+		r.rs = nil
 		return false
 	}
 
@@ -155,13 +197,136 @@ func (r *Rows) NextSet() bool {
 	return true
 }
 
-func (r *Rows) Scan(dest ...interface{}) error {
-	if r.Err() != nil {
-		return r.Err()
+// NextResultSet advances to the next result set, like [Rows.NextSet]. It is
+// provided for compatibility with [sql.Rows].
+func (r *Rows) NextResultSet() bool {
+	return r.NextSet()
+}
+
+// Columns returns the column names of the current result set.
+func (r *Rows) Columns() ([]string, error) {
+	if r == nil {
+		return nil, sql.ErrNoRows
 	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.closed {
+		return nil, errRowsClosed
+	}
+	if r.rs == nil {
+		return nil, nil
+	}
+	return r.rs.Results.Columns, nil
+}
 
-	if r.current >= len(r.rs.Results.Rows) {
-		return sql.ErrNoRows
+// ColumnTypes returns the types of the columns of the current result set. The
+// D1 API does not report the declared types of columns, so each type is
+// inferred from the column's values in the result set.
+func (r *Rows) ColumnTypes() ([]*ColumnType, error) {
+	cols, err := r.Columns()
+	if err != nil || cols == nil {
+		return nil, err
+	}
+	types := make([]*ColumnType, len(cols))
+	for i, name := range cols {
+		types[i] = newColumnType(name, r.rs.Results.Rows, i)
+	}
+	return types, nil
+}
+
+// ColumnType describes a column of a result set, as returned by
+// [Rows.ColumnTypes].
+type ColumnType struct {
+	name     string
+	typeName string
+	scanType reflect.Type
+	nullable bool
+}
+
+// newColumnType returns the type of the column with the given name and index,
+// inferred from its values in rows.
+func newColumnType(name string, rows [][]any, index int) *ColumnType {
+	ct := &ColumnType{name: name}
+	for _, row := range rows {
+		if index >= len(row) {
+			continue
+		}
+		v := row[index]
+		if v == nil {
+			ct.nullable = true
+			continue
+		}
+		typeName, scanType := storageClass(v)
+		switch {
+		case ct.scanType == nil:
+			ct.typeName, ct.scanType = typeName, scanType
+		case ct.typeName != typeName || ct.scanType != scanType:
+			ct.typeName, ct.scanType = "", reflect.TypeFor[any]()
+		}
+	}
+	if ct.scanType == nil {
+		ct.scanType = reflect.TypeFor[any]()
+	}
+	return ct
+}
+
+// storageClass returns the SQLite storage class of v, a value of a result, and
+// the Go type to scan it into. Values of other types, as returned by column
+// transformers, have no storage class.
+func storageClass(v any) (string, reflect.Type) {
+	switch v.(type) {
+	case int64:
+		return "INTEGER", reflect.TypeFor[int64]()
+	case float64:
+		return "REAL", reflect.TypeFor[float64]()
+	case string:
+		return "TEXT", reflect.TypeFor[string]()
+	case []byte, []any:
+		return "BLOB", reflect.TypeFor[[]byte]()
+	}
+	return "", reflect.TypeOf(v)
+}
+
+// Name returns the name of the column.
+func (c *ColumnType) Name() string {
+	return c.name
+}
+
+// DatabaseTypeName returns the SQLite storage class of the column's values:
+// "INTEGER", "REAL", "TEXT", or "BLOB". It returns an empty string if the
+// column has no non-NULL values, or values of more than one storage class.
+func (c *ColumnType) DatabaseTypeName() string {
+	return c.typeName
+}
+
+// ScanType returns a Go type suitable for scanning the column's values: int64,
+// float64, string, or []byte, according to their storage class, or the type
+// of an empty interface if it is not known.
+func (c *ColumnType) ScanType() reflect.Type {
+	return c.scanType
+}
+
+// Nullable reports whether the column contains NULL values in the result set.
+// Whether the column can hold NULL in the database is not known, so ok is
+// true only if it does.
+func (c *ColumnType) Nullable() (nullable, ok bool) {
+	return c.nullable, c.nullable
+}
+
+// Close closes the Rows, after which Next and NextSet return false. Since all
+// results are retrieved with the query, Close does not release any resources
+// and always returns nil; it is provided for compatibility with [sql.Rows].
+func (r *Rows) Close() error {
+	if r != nil {
+		r.closed = true
+	}
+	return nil
+}
+
+func (r *Rows) Scan(dest ...interface{}) error {
+	if err := r.checkRow(); err != nil {
+		return err
 	}
 
 	row := r.rs.Results.Rows[r.current]
@@ -169,7 +334,7 @@ func (r *Rows) Scan(dest ...interface{}) error {
 		if i >= len(dest) {
 			break
 		}
-		if err := assign(dest[i], col); err != nil {
+		if err := assignValue(dest[i], col, r.strict); err != nil {
 			return fmt.Errorf("column %d: %w", i, err)
 		}
 	}
@@ -180,14 +345,12 @@ func (r *Rows) Scan(dest ...interface{}) error {
 // ScanStruct scans the current row into a struct. The struct fields are matched
 // to the column names in the result set. The struct fields can be tagged with
 // `db`, `sql`, or `json` to specify the column name. If no tag is present, the
-// field name is used.
+// field name is used. NULL values are scanned as the zero value of a field,
+// unless its tag has the notnull option, as in `db:"name,notnull"`, in which
+// case scanning NULL into it fails with an error.
 func (r *Rows) ScanStruct(dest interface{}) error {
-	if r.Err() != nil {
-		return r.Err()
-	}
-
-	if r.current >= len(r.rs.Results.Rows) {
-		return sql.ErrNoRows
+	if err := r.checkRow(); err != nil {
+		return err
 	}
 
 	v := reflect.ValueOf(dest)
@@ -202,10 +365,109 @@ func (r *Rows) ScanStruct(dest interface{}) error {
 	if r.fieldMap == nil {
 		r.fieldMap = createFieldMap(v.Type())
 	}
-	return scanStructWithMap(r.rs.Results.Columns, r.rs.Results.Rows[r.current], v, r.fieldMap)
+	return scanStructWithMap(r.rs.Results.Columns, r.rs.Results.Rows[r.current], v, r.fieldMap, r.strict)
+}
+
+// ScanSlice scans every column of the current row into the slice that dest
+// points to, as described for [Row.ScanSlice].
+func (r *Rows) ScanSlice(dest any) error {
+	if err := r.checkRow(); err != nil {
+		return err
+	}
+	return scanSlice(r.rs.Results.Rows[r.current], dest, r.strict)
+}
+
+// scanSlice scans the values of row into the elements of the slice that dest
+// points to, resizing it to len(row).
+func scanSlice(row []any, dest any, strict bool) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be a non-nil pointer to a slice")
+	}
+	slice := v.Elem()
+	if slice.Cap() >= len(row) {
+		slice.SetLen(len(row))
+	} else {
+		slice.Set(reflect.MakeSlice(slice.Type(), len(row), len(row)))
+	}
+	for i, col := range row {
+		if err := assignValue(slice.Index(i).Addr().Interface(), col, strict); err != nil {
+			return fmt.Errorf("column %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ScanAll scans the remaining rows of the current result set, appending each
+// to the slice that dest points to, and advances past them. If the slice's
+// element type is a struct, columns are matched to fields as with
+// [Rows.ScanStruct]; otherwise, the first column of each row is scanned into
+// each element. If no rows remain, dest is left unchanged and nil is returned.
+// Later result sets are not scanned; see [Rows.ScanAllSets].
+//
+// Example usage:
+//
+//	var users []User
+//	err := h.QueryRows(ctx, "SELECT * FROM users").ScanAll(&users)
+func (r *Rows) ScanAll(dest any) error {
+	v, err := r.checkScanAll(dest)
+	if err != nil || r.rs == nil {
+		return err
+	}
+	start := r.current + 1
+	if start >= len(r.rs.Results.Rows) {
+		return nil
+	}
+	r.current = len(r.rs.Results.Rows)
+	return appendRows(r.rs.Results.Columns, r.rs.Results.Rows[start:], v, r.strict)
 }
 
+// ScanAllSets scans the remaining rows of the current result set and of all
+// following result sets, appending each to the slice that dest points to, as
+// with [Rows.ScanAll]. Afterwards, there are no more result sets. Struct fields
+// are matched to the columns of each result set separately.
+func (r *Rows) ScanAllSets(dest any) error {
+	if _, err := r.checkScanAll(dest); err != nil {
+		return err
+	}
+	for {
+		if err := r.ScanAll(dest); err != nil {
+			return err
+		}
+		if !r.NextSet() {
+			return nil
+		}
+	}
+}
+
+// checkScanAll returns an error if r cannot be scanned or dest is not a
+// non-nil pointer to a slice, and otherwise returns the value of dest.
+func (r *Rows) checkScanAll(dest any) (reflect.Value, error) {
+	if r == nil {
+		return reflect.Value{}, sql.ErrNoRows
+	}
+	if r.err != nil {
+		return reflect.Value{}, r.err
+	}
+	if r.closed {
+		return reflect.Value{}, errRowsClosed
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("dest must be a non-nil pointer to slice")
+	}
+	return v, nil
+}
+
+// assign converts src to the type of the value that dest points to, and stores
+// it there, as with [Row.Scan].
 func assign(dest, src any) error {
+	return assignValue(dest, src, false)
+}
+
+// assignValue is like assign, but if strict is true, only the conversions
+// allowed by [WithStrictScan] are made.
+func assignValue(dest, src any, strict bool) error {
 	// Fast path for nil
 	if src == nil {
 		reflect.ValueOf(dest).Elem().Set(reflect.Zero(reflect.TypeOf(dest).Elem()))
@@ -222,14 +484,28 @@ func assign(dest, src any) error {
 	sv := reflect.ValueOf(src)
 	st := sv.Type()
 
+	// Handle types registered with RegisterType
+	if decode := typeDecoder(dt); decode != nil {
+		return decode(src, &dv)
+	}
+
 	// Handle scannable interfaces (sql.Scanner, etc)
 	if scanner, ok := dv.Interface().(sql.Scanner); ok {
 		return scanner.Scan(src)
 	}
 
+	// A json.RawMessage receives the JSON document of a TEXT column verbatim
+	if dt == reflect.TypeOf(json.RawMessage(nil)) {
+		return assignRawJSON(dv, src)
+	}
+
+	if strict {
+		return assignStrict(dv, sv)
+	}
+
 	// Handle special cases (e.g., int -> string) before ConvertibleTo().
 	// Otherwise, 42 converts to "*" not "42".
-	if dt.Kind() == reflect.String && (st.Kind() == reflect.Int || st.Kind() == reflect.Uint) {
+	if dt.Kind() == reflect.String {
 		switch st.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			dv.SetString(strconv.FormatInt(sv.Int(), 10))
@@ -324,6 +600,14 @@ func assign(dest, src any) error {
 			return nil
 		}
 
+	case reflect.Map, reflect.Slice:
+		// TEXT holding a JSON document, as returned by json_extract
+		if sv.Kind() == reflect.String {
+			if err := json.Unmarshal([]byte(sv.String()), dv.Addr().Interface()); err == nil {
+				return nil
+			}
+		}
+
 	case reflect.Struct:
 		// If a numeric is mapped to a time.Time, it is treated as a unix timestamp
 		if dt == reflect.TypeOf(time.Time{}) {
@@ -350,60 +634,144 @@ func assign(dest, src any) error {
 					return nil
 				}
 			}
+		} else if sv.Kind() == reflect.String {
+			// TEXT holding a JSON object, as returned by json_extract
+			if err := json.Unmarshal([]byte(sv.String()), dv.Addr().Interface()); err == nil {
+				return nil
+			}
 		}
 	}
 	return fmt.Errorf("cannot convert value %v (type %v.%v) to destination type %v.%v", src, st.PkgPath(), st.Name(), dt.PkgPath(), dt.Name())
 }
 
-func createFieldMap(t reflect.Type) map[string]int {
-	fieldMap := make(map[string]int)
+// assignRawJSON stores src in dv, a json.RawMessage. Strings and byte slices,
+// which hold the JSON document of a TEXT or BLOB column, are copied without
+// being parsed or validated, and numbers and booleans are stored as their JSON
+// representation.
+func assignRawJSON(dv reflect.Value, src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = bytes.Clone(v)
+	default:
+		var err error
+		if raw, err = json.Marshal(src); err != nil {
+			return fmt.Errorf("cannot convert value %v (type %T) to json.RawMessage: %w", src, src, err)
+		}
+	}
+	dv.SetBytes(raw)
+	return nil
+}
+
+// assignStrict stores sv in dv if its type is assignable to dv's, or if both
+// are integers, floating-point numbers, or strings, and the value fits. Integers
+// may also be stored in floating-point destinations that represent them
+// exactly, because D1 returns REAL values without a fractional part as
+// integers.
+func assignStrict(dv, sv reflect.Value) error {
+	dt, st := dv.Type(), sv.Type()
+	if st.AssignableTo(dt) {
+		dv.Set(sv)
+		return nil
+	}
+	switch dt.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if sv.CanInt() && !dv.OverflowInt(sv.Int()) {
+			dv.SetInt(sv.Int())
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if sv.CanInt() && sv.Int() >= 0 && !dv.OverflowUint(uint64(sv.Int())) {
+			dv.SetUint(uint64(sv.Int()))
+			return nil
+		}
+		if sv.CanUint() && !dv.OverflowUint(sv.Uint()) {
+			dv.SetUint(sv.Uint())
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if sv.CanFloat() && !dv.OverflowFloat(sv.Float()) {
+			dv.SetFloat(sv.Float())
+			return nil
+		}
+		if sv.CanInt() && intFitsFloat(sv.Int(), dt.Bits()) {
+			dv.SetFloat(float64(sv.Int()))
+			return nil
+		}
+	case reflect.String:
+		if sv.Kind() == reflect.String {
+			dv.SetString(sv.String())
+			return nil
+		}
+	}
+	return fmt.Errorf("strict scan: cannot assign value %v (type %v) to destination type %v", sv.Interface(), st, dt)
+}
+
+// intFitsFloat reports whether i is represented exactly by a floating-point
+// number of the given size in bits, 32 or 64.
+func intFitsFloat(i int64, bits int) bool {
+	f := float64(i)
+	if bits == 32 {
+		f = float64(float32(f))
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which does not fit in an int64
+	return f < math.MaxInt64 && int64(f) == i
+}
+
+// structField describes the struct field that a column is scanned into.
+type structField struct {
+	index   int  // index of the field within the struct
+	notNull bool // the field is tagged notnull, so NULL is an error
+}
+
+func createFieldMap(t reflect.Type) map[string]structField {
+	fieldMap := make(map[string]structField)
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 
-		// Check for db tag first
-		if tag := field.Tag.Get("db"); tag != "" {
-			if tag == "-" {
-				continue
+		// Use the first of the db, sql, and json tags that is present, falling
+		// back to the field name. Options follow the name, separated by commas.
+		var tag string
+		for _, key := range []string{"db", "sql", "json"} {
+			if tag = field.Tag.Get(key); tag != "" {
+				break
 			}
-			fieldMap[tag] = i
-			continue
 		}
-
-		// Check for sql tag next
-		if tag := field.Tag.Get("sql"); tag != "" {
-			if tag == "-" {
-				continue
-			}
-			fieldMap[tag] = i
+		if tag == "-" {
 			continue
 		}
-
-		// Check for json tag next
-		if tag := field.Tag.Get("json"); tag != "" {
-			if tag == "-" {
-				continue
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		sf := structField{index: i}
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "notnull" {
+				sf.notNull = true
 			}
-			fieldMap[tag] = i
-			continue
 		}
-
-		// Fall back to field name
-		fieldMap[strings.ToLower(field.Name)] = i
+		fieldMap[name] = sf
 	}
 	return fieldMap
 }
 
-func scanStructWithMap(cols []string, row []any, v reflect.Value, fieldMap map[string]int) error {
+func scanStructWithMap(cols []string, row []any, v reflect.Value, fieldMap map[string]structField, strict bool) error {
 	for i, col := range cols {
-		if fieldIndex, ok := fieldMap[strings.ToLower(col)]; ok {
-			field := v.Field(fieldIndex)
+		if sf, ok := fieldMap[strings.ToLower(col)]; ok {
+			field := v.Field(sf.index)
 			if field.CanSet() {
 				if row[i] == nil {
+					if sf.notNull {
+						return fmt.Errorf("error assigning column %s: NULL value for field %s tagged notnull",
+							col, v.Type().Field(sf.index).Name)
+					}
 					field.Set(reflect.Zero(field.Type()))
 					continue
 				}
 				src := reflect.ValueOf(row[i]).Interface()
-				if err := assign(field.Addr().Interface(), src); err != nil {
+				if err := assignValue(field.Addr().Interface(), src, strict); err != nil {
 					return fmt.Errorf("error assigning column %s: %w", col, err)
 				}
 			}
@@ -412,6 +780,38 @@ func scanStructWithMap(cols []string, row []any, v reflect.Value, fieldMap map[s
 	return nil
 }
 
+// appendRows scans each of rows into a new element appended to the slice that
+// dest points to. Struct elements are matched to cols by name, as with
+// [ScanStructs]; elements of any other type receive the first column. If strict
+// is true, values are converted as with [WithStrictScan].
+func appendRows(cols []string, rows [][]any, dest reflect.Value, strict bool) error {
+	slice := dest.Elem()
+	elemType := slice.Type().Elem()
+	isStruct := elemType.Kind() == reflect.Struct && elemType != reflect.TypeOf(time.Time{})
+
+	var fieldMap map[string]structField
+	if isStruct {
+		fieldMap = createFieldMap(elemType)
+	}
+
+	for i, row := range rows {
+		elem := reflect.New(elemType).Elem()
+		if isStruct {
+			if err := scanStructWithMap(cols, row, elem, fieldMap, strict); err != nil {
+				return fmt.Errorf("error scanning row %d: %w", i, err)
+			}
+		} else if len(row) > 0 {
+			if err := assignValue(elem.Addr().Interface(), row[0], strict); err != nil {
+				return fmt.Errorf("error scanning row %d: %w", i, err)
+			}
+		}
+		slice = reflect.Append(slice, elem)
+	}
+
+	dest.Elem().Set(slice)
+	return nil
+}
+
 func ScanStructs(cols []string, rows [][]any, dest interface{}) error {
 	v := reflect.ValueOf(dest)
 	if v.Kind() != reflect.Ptr || v.IsNil() {
@@ -431,7 +831,7 @@ func ScanStructs(cols []string, rows [][]any, dest interface{}) error {
 
 	// Process each row
 	for i, row := range rows {
-		if err := scanStructWithMap(cols, row, newSlice.Index(i), fieldMap); err != nil {
+		if err := scanStructWithMap(cols, row, newSlice.Index(i), fieldMap, false); err != nil {
 			return fmt.Errorf("error scanning row %d: %w", i, err)
 		}
 	}
@@ -1,6 +1,10 @@
 package cfd1
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -76,6 +80,13 @@ func TestAssign(t *testing.T) {
 		// Byte Slice
 		{"Convert []byte to string", new(string), []byte("abc"), "abc", false},
 		{"Assign string to []byte", new([]byte), "hello", []byte("hello"), false},
+
+		// json.RawMessage
+		{"Assign JSON string to json.RawMessage", new(json.RawMessage), `{"a": [1, 2]}`, json.RawMessage(`{"a": [1, 2]}`), false},
+		{"Assign []byte to json.RawMessage", new(json.RawMessage), []byte(`[1]`), json.RawMessage(`[1]`), false},
+		{"Assign int to json.RawMessage", new(json.RawMessage), int64(42), json.RawMessage(`42`), false},
+		{"Assign bool to json.RawMessage", new(json.RawMessage), true, json.RawMessage(`true`), false},
+		{"Assign nil to json.RawMessage", new(json.RawMessage), nil, json.RawMessage(nil), false},
 	}
 
 	for _, tt := range tests {
@@ -93,3 +104,343 @@ func TestAssign(t *testing.T) {
 		})
 	}
 }
+
+func TestRowsEmptyResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		result []RawQueryResult
+	}{
+		{"Nil", nil},
+		{"Empty", []RawQueryResult{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := newRows(tt.result, false, nil)
+			if rows.Next() {
+				t.Error("Next returned true")
+			}
+			if rows.NextSet() {
+				t.Error("NextSet returned true")
+			}
+			if err := rows.Err(); err != nil {
+				t.Errorf("Err: got %v, want nil", err)
+			}
+			var x int
+			if err := rows.Scan(&x); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("Scan: got %v, want sql.ErrNoRows", err)
+			}
+			if cols, err := rows.Columns(); cols != nil || err != nil {
+				t.Errorf("Columns: got %v, %v", cols, err)
+			}
+		})
+	}
+}
+
+func TestRowsLoop(t *testing.T) {
+	// The loop used with sql.Rows reads every row, and reports no error for an
+	// empty result or once the rows are exhausted
+	for _, n := range []int{0, 1, 3} {
+		var rows [][]any
+		for i := range n {
+			rows = append(rows, []any{int64(i)})
+		}
+		r := newRows([]RawQueryResult{rawResult([]string{"x"}, rows...)}, false, nil)
+		var got []int
+		for r.Next() {
+			var x int
+			if err := r.Scan(&x); err != nil {
+				t.Fatalf("%d rows: Scan: %v", n, err)
+			}
+			got = append(got, x)
+		}
+		if err := r.Err(); err != nil {
+			t.Errorf("%d rows: Err: got %v, want nil", n, err)
+		}
+		if len(got) != n {
+			t.Errorf("%d rows: read %v", n, got)
+		}
+		var x int
+		if err := r.Scan(&x); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("%d rows: Scan after the last row: got %v, want sql.ErrNoRows", n, err)
+		}
+	}
+
+	// Scanning before Next fails rather than panicking
+	var x int
+	r := newRows([]RawQueryResult{rawResult([]string{"x"}, []any{int64(1)})}, false, nil)
+	if err := r.Scan(&x); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Scan before Next: got %v, want sql.ErrNoRows", err)
+	}
+
+	queryErr := errors.New("query failed")
+	if err := newRows(nil, false, queryErr).Err(); err != queryErr {
+		t.Errorf("Err: got %v, want the query's error", err)
+	}
+}
+
+func TestRowsColumnTypes(t *testing.T) {
+	result := rawResult([]string{"id", "score", "name", "data", "mixed", "empty"},
+		[]any{int64(1), 1.5, "a", []any{1.0, 2.0}, int64(1), nil},
+		[]any{int64(2), nil, "b", nil, "x", nil},
+	)
+	types, err := newRows([]RawQueryResult{result}, false, nil).ColumnTypes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name     string
+		typeName string
+		scanType reflect.Type
+		nullable bool
+	}{
+		{"id", "INTEGER", reflect.TypeFor[int64](), false},
+		{"score", "REAL", reflect.TypeFor[float64](), true},
+		{"name", "TEXT", reflect.TypeFor[string](), false},
+		{"data", "BLOB", reflect.TypeFor[[]byte](), true},
+		{"mixed", "", reflect.TypeFor[any](), false},
+		{"empty", "", reflect.TypeFor[any](), true},
+	}
+	if len(types) != len(tests) {
+		t.Fatalf("got %d column types, want %d", len(types), len(tests))
+	}
+	for i, tt := range tests {
+		ct := types[i]
+		nullable, ok := ct.Nullable()
+		if ct.Name() != tt.name || ct.DatabaseTypeName() != tt.typeName || ct.ScanType() != tt.scanType ||
+			nullable != tt.nullable || ok != tt.nullable {
+			t.Errorf("column %d: got %q, %q, %v, (%v, %v); want %q, %q, %v, nullable %v", i,
+				ct.Name(), ct.DatabaseTypeName(), ct.ScanType(), nullable, ok, tt.name, tt.typeName, tt.scanType, tt.nullable)
+		}
+	}
+
+	if types, err := newRows(nil, false, nil).ColumnTypes(); types != nil || err != nil {
+		t.Errorf("no result sets: got %v, %v", types, err)
+	}
+}
+
+func TestRowsNextSetAfterEmptySet(t *testing.T) {
+	var rs1, rs2 RawQueryResult
+	rs1.Results.Rows = [][]any{}
+	rs2.Results.Columns = []string{"x"}
+	rs2.Results.Rows = [][]any{{1.0}}
+	rows := newRows([]RawQueryResult{rs1, rs2}, false, nil)
+
+	if rows.Next() {
+		t.Fatal("Next returned true for empty result set")
+	}
+	if !rows.NextSet() {
+		t.Fatal("NextSet returned false")
+	}
+	var x int
+	if !rows.Next() || rows.Scan(&x) != nil || x != 1 {
+		t.Errorf("unexpected second result set: x = %d", x)
+	}
+	if rows.NextSet() || rows.Next() {
+		t.Error("expected no more result sets")
+	}
+}
+
+func TestRowsScanAll(t *testing.T) {
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	newTestRows := func() *Rows {
+		var rs1, rs2 RawQueryResult
+		rs1.Results.Columns = []string{"id", "name"}
+		rs1.Results.Rows = [][]any{{1.0, "alice"}, {2.0, "bob"}, {3.0, "carol"}}
+		rs2.Results.Columns = []string{"name", "id"}
+		rs2.Results.Rows = [][]any{{"dave", 4.0}}
+		return newRows([]RawQueryResult{rs1, rs2}, false, nil)
+	}
+
+	t.Run("Structs", func(t *testing.T) {
+		rows := newTestRows()
+		var users []user
+		if err := rows.ScanAll(&users); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []user{{1, "alice"}, {2, "bob"}, {3, "carol"}}
+		if !reflect.DeepEqual(users, want) {
+			t.Errorf("got %v, want %v", users, want)
+		}
+		if rows.Next() {
+			t.Error("Next returned true after ScanAll")
+		}
+	})
+
+	t.Run("Remaining scalars", func(t *testing.T) {
+		rows := newTestRows()
+		rows.Next()
+		var ids []int
+		if err := rows.ScanAll(&ids); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := []int{2, 3}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("got %v, want %v", ids, want)
+		}
+	})
+
+	t.Run("All sets", func(t *testing.T) {
+		rows := newTestRows()
+		var users []user
+		if err := rows.ScanAllSets(&users); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []user{{1, "alice"}, {2, "bob"}, {3, "carol"}, {4, "dave"}}
+		if !reflect.DeepEqual(users, want) {
+			t.Errorf("got %v, want %v", users, want)
+		}
+	})
+
+	t.Run("Invalid destination", func(t *testing.T) {
+		var users []user
+		if err := newTestRows().ScanAll(users); err == nil {
+			t.Error("expected error for non-pointer destination")
+		}
+	})
+}
+
+func TestScanStructNotNull(t *testing.T) {
+	type user struct {
+		ID    int    `db:"id,notnull"`
+		Name  string `db:"name"`
+		Email string `json:"email,omitempty"`
+		Age   int    `db:",notnull"`
+	}
+
+	tests := []struct {
+		name     string
+		row      []any
+		expected user
+		wantErr  bool
+	}{
+		{"All set", []any{1.0, "a", "a@example.com", 30.0}, user{1, "a", "a@example.com", 30}, false},
+		{"NULL in nullable field", []any{1.0, nil, nil, 30.0}, user{ID: 1, Age: 30}, false},
+		{"NULL in notnull field", []any{nil, "a", "a@example.com", 30.0}, user{}, true},
+		{"NULL in notnull field without name", []any{1.0, "a", "a@example.com", nil}, user{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := rawResult([]string{"id", "name", "email", "age"}, tt.row)
+			var got user
+			err := newRow(&result, false, nil).ScanStruct(&got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScanStruct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("ScanStruct() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanStructRawJSON(t *testing.T) {
+	type event struct {
+		ID      int             `db:"id"`
+		Payload json.RawMessage `db:"payload"`
+	}
+	payload := `{"kind": "signup", "tags": ["a", "b"], "n": 1.50}`
+	for _, strict := range []bool{false, true} {
+		result := rawResult([]string{"id", "payload"}, []any{int64(1), payload})
+		var got event
+		if err := newRow(&result, strict, nil).ScanStruct(&got); err != nil {
+			t.Fatalf("strict %v: unexpected error: %v", strict, err)
+		}
+		if string(got.Payload) != payload {
+			t.Errorf("strict %v: got payload %s, want %s", strict, got.Payload, payload)
+		}
+	}
+
+	// The destination does not share memory with a []byte source
+	src := []byte(`{"a": 1}`)
+	var raw json.RawMessage
+	if err := assign(&raw, src); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	src[0] = 'x'
+	if string(raw) != `{"a": 1}` {
+		t.Errorf("got %s after modifying the source", raw)
+	}
+}
+
+func TestScanSlice(t *testing.T) {
+	tests := []struct {
+		name     string
+		row      []any
+		dest     any
+		expected any
+		wantErr  bool
+	}{
+		{"Strings", []any{"a", 2.0, nil}, &[]string{}, &[]string{"a", "2", ""}, false},
+		{"Ints", []any{1.0, "2", 3.0}, &[]int{9, 9, 9, 9}, &[]int{1, 2, 3}, false},
+		{"Any", []any{"a", 1.0}, &[]any{}, &[]any{"a", 1.0}, false},
+		{"Unconvertible", []any{1.0, "x"}, &[]int{}, nil, true},
+		{"Not a slice", []any{1.0}, new(int), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := rawResult([]string{"c1", "c2", "c3"}[:len(tt.row)], tt.row)
+			err := newRow(&result, false, nil).ScanSlice(tt.dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScanSlice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.dest, tt.expected) {
+				t.Errorf("ScanSlice() = %v, want %v", tt.dest, tt.expected)
+			}
+
+			rows := newRows([]RawQueryResult{result}, false, nil)
+			rows.Next()
+			if err := rows.ScanSlice(tt.dest); (err != nil) != tt.wantErr {
+				t.Errorf("Rows.ScanSlice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStrictScan(t *testing.T) {
+	type status string
+	tests := []struct {
+		name     string
+		src      any
+		dest     any
+		expected any
+		wantErr  bool
+	}{
+		{"Int to int", int64(42), new(int), 42, false},
+		{"Int to float", int64(2), new(float64), 2.0, false},
+		{"Int beyond float64 precision", int64(1<<53 + 1), new(float64), nil, true},
+		{"Int beyond float32 precision", int64(1<<24 + 1), new(float32), nil, true},
+		{"Large int exact in float64", int64(1 << 60), new(float64), float64(1 << 60), false},
+		{"Max int", int64(math.MaxInt64), new(float64), nil, true},
+		{"Int to uint", int64(7), new(uint8), uint8(7), false},
+		{"Int overflow", int64(300), new(int8), nil, true},
+		{"Negative to uint", int64(-1), new(uint), nil, true},
+		{"Float to float", 2.5, new(float32), float32(2.5), false},
+		{"Float to int", 2.5, new(int), nil, true},
+		{"String to string", "a", new(string), "a", false},
+		{"String to named string", "active", new(status), status("active"), false},
+		{"String to int", "42", new(int), nil, true},
+		{"Int to string", int64(42), new(string), nil, true},
+		{"Int to bool", int64(1), new(bool), nil, true},
+		{"Int to time", int64(1700000000), new(time.Time), nil, true},
+		{"Any", "a", new(any), "a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := rawResult([]string{"c"}, []any{tt.src})
+			err := newRow(&result, true, nil).Scan(tt.dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := reflect.ValueOf(tt.dest).Elem().Interface(); !tt.wantErr && got != tt.expected {
+				t.Errorf("Scan() = %#v, want %#v", got, tt.expected)
+			}
+
+			// Without strict scanning, every value converts
+			if err := newRow(&result, false, nil).Scan(tt.dest); err != nil {
+				t.Errorf("lenient Scan() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package cfd1
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Priority is the scheduling priority of a request, used by clients created
+// with [WithPriorityScheduler]. Requests are given [PriorityNormal] unless
+// their context sets a different priority with [WithPriority].
+type Priority int
+
+const (
+	PriorityLow    Priority = iota // Background work, such as batch jobs and exports
+	PriorityNormal                 // The default priority
+	PriorityHigh                   // Latency-sensitive work, such as interactive queries
+
+	numPriorities = 3
+)
+
+// priorityKey is the context key for a request's Priority.
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx that gives requests made with it priority
+// p, when the client was created with [WithPriorityScheduler].
+//
+// Example usage:
+//
+//	ctx = cfd1.WithPriority(ctx, cfd1.PriorityLow)
+//	err := h.Execute(ctx, "DELETE FROM events WHERE created_at < ?", cutoff)
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// priorityFrom returns the priority set on ctx, or PriorityNormal if there is
+// none or it is out of range.
+func priorityFrom(ctx context.Context) Priority {
+	p, ok := ctx.Value(priorityKey{}).(Priority)
+	if !ok || p < PriorityLow || p > PriorityHigh {
+		return PriorityNormal
+	}
+	return p
+}
+
+// WithPriorityScheduler limits the client to maxConcurrent requests in flight
+// at once. When all slots are in use, waiting requests are started in order of
+// their [Priority], set on the request's context with [WithPriority], and in
+// the order they arrived within each priority. Each retry of a request waits
+// for a slot again. A request whose context is canceled while waiting fails
+// with an error wrapping [ErrCanceled].
+func WithPriorityScheduler(maxConcurrent int) ClientOption {
+	return func(c *Client) {
+		c.scheduler = newScheduler(maxConcurrent)
+	}
+}
+
+// scheduler limits the number of concurrent requests, granting free slots to
+// waiting requests in priority order.
+type scheduler struct {
+	mu      sync.Mutex
+	free    int
+	waiting [numPriorities][]chan struct{} // FIFO queues, indexed by priority
+}
+
+func newScheduler(maxConcurrent int) *scheduler {
+	return &scheduler{free: max(maxConcurrent, 1)}
+}
+
+// acquire waits for a free slot, returning an error if ctx is done first.
+func (s *scheduler) acquire(ctx context.Context) error {
+	p := priorityFrom(ctx)
+
+	s.mu.Lock()
+	if s.free > 0 && s.numWaiting() == 0 {
+		s.free--
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	s.waiting[p] = append(s.waiting[p], ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if i := slices.Index(s.waiting[p], ready); i >= 0 {
+			s.waiting[p] = slices.Delete(s.waiting[p], i, i+1)
+			return ctx.Err()
+		}
+		// The slot was granted concurrently with cancellation; pass it on.
+		s.releaseLocked()
+		return ctx.Err()
+	}
+}
+
+// release frees a slot acquired with acquire, granting it to the
+// highest-priority waiting request, if any.
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+func (s *scheduler) releaseLocked() {
+	for p := numPriorities - 1; p >= 0; p-- {
+		if len(s.waiting[p]) > 0 {
+			ready := s.waiting[p][0]
+			s.waiting[p] = s.waiting[p][1:]
+			close(ready)
+			return
+		}
+	}
+	s.free++
+}
+
+func (s *scheduler) numWaiting() int {
+	n := 0
+	for _, q := range s.waiting {
+		n += len(q)
+	}
+	return n
+}
@@ -0,0 +1,60 @@
+package cfd1
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSchedulerPriority(t *testing.T) {
+	s := newScheduler(1)
+	if err := s.acquire(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	order := make(chan Priority, 3)
+	waitFor := func(n int) {
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			s.mu.Lock()
+			waiting := s.numWaiting()
+			s.mu.Unlock()
+			if waiting == n {
+				return
+			}
+		}
+		t.Fatalf("timed out waiting for %d queued requests", n)
+	}
+	for i, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+		go func() {
+			if err := s.acquire(WithPriority(context.Background(), p)); err == nil {
+				order <- p
+				s.release()
+			}
+		}()
+		waitFor(i + 1)
+	}
+
+	s.release()
+	for _, want := range []Priority{PriorityHigh, PriorityNormal, PriorityLow} {
+		if got := <-order; got != want {
+			t.Errorf("unexpected order: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSchedulerCanceled(t *testing.T) {
+	s := newScheduler(1)
+	s.acquire(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	s.release()
+	if err := s.acquire(context.Background()); err != nil {
+		t.Errorf("slot was not freed after cancellation: %v", err)
+	}
+}
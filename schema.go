@@ -0,0 +1,171 @@
+package cfd1
+
+import (
+	"context"
+	"fmt"
+)
+
+// schemaSQL lists the user-defined objects of a database, with a row for each
+// column of each table. D1's internal _cf_ tables and SQLite's own sqlite_
+// tables are excluded.
+const schemaSQL = `SELECT m.type, m.name, m.tbl_name, m.sql, p.name, p.type, p."notnull", p.dflt_value, p.pk ` +
+	`FROM sqlite_master m LEFT JOIN pragma_table_info(m.name) p ON m.type = 'table' ` +
+	`WHERE m.name NOT LIKE 'sqlite\_%' ESCAPE '\' AND m.name NOT LIKE '\_cf\_%' ESCAPE '\' ` +
+	`ORDER BY m.type, m.name, p.cid`
+
+// SchemaObject is a table, index, view, or trigger in a database's schema.
+type SchemaObject struct {
+	Type      string         // "table", "index", "view", or "trigger"
+	Name      string         // Name of the object
+	TableName string         // Table the object belongs to; same as Name for tables
+	SQL       string         // SQL that created the object; empty for automatic indexes
+	Columns   []SchemaColumn // Columns of a table, in order; nil for other types
+}
+
+// SchemaColumn is a column of a table in a database's schema.
+type SchemaColumn struct {
+	Name       string // Name of the column
+	Type       string // Declared type of the column, as written in CREATE TABLE
+	NotNull    bool   // Whether the column has a NOT NULL constraint
+	Default    string // Default value expression; empty if the column has none
+	PrimaryKey int    // 1-based position within the primary key; 0 if not part of it
+}
+
+// SchemaDiff describes the differences between the schemas of two databases,
+// A and B, as returned by [Client.SchemaDiff]. Objects are identified by their
+// type and name.
+type SchemaDiff struct {
+	OnlyInA        []SchemaObject // Objects present in A but not in B
+	OnlyInB        []SchemaObject // Objects present in B but not in A
+	ChangedColumns []ColumnDiff   // Column differences in tables present in both
+}
+
+// ColumnDiff describes a column that differs between two versions of a table.
+// A or B is nil if the column is only present in the other version.
+type ColumnDiff struct {
+	Table  string
+	Column string
+	A      *SchemaColumn
+	B      *SchemaColumn
+}
+
+// Empty reports whether the schemas compared were identical.
+func (d SchemaDiff) Empty() bool {
+	return len(d.OnlyInA) == 0 && len(d.OnlyInB) == 0 && len(d.ChangedColumns) == 0
+}
+
+// Schema returns the user-defined tables, indexes, views, and triggers of a
+// database, as recorded in its sqlite_master table, ordered by type and name.
+// The columns of each table are included.
+func (c *Client) Schema(ctx context.Context, databaseID string) ([]SchemaObject, error) {
+	result, err := c.RawQuery(WithoutDefaultLimit(ctx), databaseID, schemaSQL)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+
+	var objects []SchemaObject
+	for _, row := range result[0].Results.Rows {
+		typ, _ := row[0].(string)
+		name, _ := row[1].(string)
+		if n := len(objects); n == 0 || objects[n-1].Type != typ || objects[n-1].Name != name {
+			tableName, _ := row[2].(string)
+			sql, _ := row[3].(string)
+			objects = append(objects, SchemaObject{Type: typ, Name: name, TableName: tableName, SQL: sql})
+		}
+		if row[4] == nil {
+			continue
+		}
+
+		col := SchemaColumn{}
+		col.Name, _ = row[4].(string)
+		col.Type, _ = row[5].(string)
+		notNull, _ := row[6].(int64)
+		col.NotNull = notNull != 0
+		col.Default, _ = row[7].(string)
+		pk, _ := row[8].(int64)
+		col.PrimaryKey = int(pk)
+		objects[len(objects)-1].Columns = append(objects[len(objects)-1].Columns, col)
+	}
+	return objects, nil
+}
+
+// SchemaDiff compares the schemas of two databases, given by their IDs, and
+// reports the objects present in only one of them, and the columns that differ
+// between tables present in both. Differences in column order are not reported.
+//
+// Example usage:
+//
+//	diff, err := client.SchemaDiff(ctx, migratedDB, expectedDB)
+//	if err != nil {
+//	    // handle error
+//	}
+//	if !diff.Empty() {
+//	    fmt.Printf("schemas differ: %+v\n", diff)
+//	}
+func (c *Client) SchemaDiff(ctx context.Context, dbA, dbB string) (SchemaDiff, error) {
+	a, err := c.Schema(ctx, dbA)
+	if err != nil {
+		return SchemaDiff{}, fmt.Errorf("reading schema of %s: %w", dbA, err)
+	}
+	b, err := c.Schema(ctx, dbB)
+	if err != nil {
+		return SchemaDiff{}, fmt.Errorf("reading schema of %s: %w", dbB, err)
+	}
+	return diffSchemas(a, b), nil
+}
+
+// diffSchemas compares two lists of schema objects.
+func diffSchemas(a, b []SchemaObject) SchemaDiff {
+	type key struct{ typ, name string }
+	inB := make(map[key]*SchemaObject, len(b))
+	for i := range b {
+		inB[key{b[i].Type, b[i].Name}] = &b[i]
+	}
+
+	var diff SchemaDiff
+	seen := make(map[key]bool, len(a))
+	for _, objA := range a {
+		k := key{objA.Type, objA.Name}
+		seen[k] = true
+		objB, ok := inB[k]
+		if !ok {
+			diff.OnlyInA = append(diff.OnlyInA, objA)
+			continue
+		}
+		diff.ChangedColumns = append(diff.ChangedColumns, diffColumns(objA.Name, objA.Columns, objB.Columns)...)
+	}
+	for _, objB := range b {
+		if !seen[key{objB.Type, objB.Name}] {
+			diff.OnlyInB = append(diff.OnlyInB, objB)
+		}
+	}
+	return diff
+}
+
+// diffColumns compares the columns of two versions of a table.
+func diffColumns(table string, a, b []SchemaColumn) []ColumnDiff {
+	inB := make(map[string]*SchemaColumn, len(b))
+	for i := range b {
+		inB[b[i].Name] = &b[i]
+	}
+
+	var diffs []ColumnDiff
+	seen := make(map[string]bool, len(a))
+	for i := range a {
+		colA := &a[i]
+		seen[colA.Name] = true
+		colB := inB[colA.Name]
+		if colB == nil || *colA != *colB {
+			diffs = append(diffs, ColumnDiff{Table: table, Column: colA.Name, A: colA, B: colB})
+		}
+	}
+	for i := range b {
+		if !seen[b[i].Name] {
+			diffs = append(diffs, ColumnDiff{Table: table, Column: b[i].Name, B: &b[i]})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,91 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	schemas := map[string]RawQueryResult{
+		"a": rawResult([]string{"type", "name", "tbl_name", "sql", "name", "type", "notnull", "dflt_value", "pk"},
+			[]any{"index", "idx_users_email", "users", "CREATE INDEX ...", nil, nil, nil, nil, nil},
+			[]any{"table", "users", "users", "CREATE TABLE ...", "id", "INTEGER", 0.0, nil, 1.0},
+			[]any{"table", "users", "users", "CREATE TABLE ...", "email", "TEXT", 1.0, nil, 0.0},
+			[]any{"table", "users", "users", "CREATE TABLE ...", "name", "TEXT", 0.0, nil, 0.0},
+		),
+		"b": rawResult([]string{"type", "name", "tbl_name", "sql", "name", "type", "notnull", "dflt_value", "pk"},
+			[]any{"table", "orders", "orders", "CREATE TABLE ...", "id", "INTEGER", 0.0, nil, 1.0},
+			[]any{"table", "users", "users", "CREATE TABLE ...", "id", "INTEGER", 0.0, nil, 1.0},
+			[]any{"table", "users", "users", "CREATE TABLE ...", "email", "TEXT", 0.0, "''", 0.0},
+		),
+	}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		db := "a"
+		if r.URL.Path == "/accounts/test-account/d1/database/b/raw" {
+			db = "b"
+		}
+		writeAPIResult(w, []RawQueryResult{schemas[db]}, nil)
+	})
+
+	diff, err := client.SchemaDiff(context.Background(), "a", "b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := func(objects []SchemaObject) []string {
+		var out []string
+		for _, o := range objects {
+			out = append(out, o.Type+" "+o.Name)
+		}
+		return out
+	}
+	if got, want := names(diff.OnlyInA), []string{"index idx_users_email"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OnlyInA: got %v, want %v", got, want)
+	}
+	if got, want := names(diff.OnlyInB), []string{"table orders"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OnlyInB: got %v, want %v", got, want)
+	}
+
+	want := []ColumnDiff{
+		{Table: "users", Column: "email",
+			A: &SchemaColumn{Name: "email", Type: "TEXT", NotNull: true},
+			B: &SchemaColumn{Name: "email", Type: "TEXT", Default: "''"}},
+		{Table: "users", Column: "name", A: &SchemaColumn{Name: "name", Type: "TEXT"}},
+	}
+	if !reflect.DeepEqual(diff.ChangedColumns, want) {
+		t.Errorf("ChangedColumns: got %+v, want %+v", diff.ChangedColumns, want)
+	}
+	if diff.Empty() {
+		t.Error("expected non-empty diff")
+	}
+}
+
+func TestSchemaDefaultLimit(t *testing.T) {
+	rows := [][]any{
+		{"table", "users", "users", "CREATE TABLE ...", "id", "INTEGER", 0.0, nil, 1.0},
+		{"table", "users", "users", "CREATE TABLE ...", "email", "TEXT", 1.0, nil, 0.0},
+		{"table", "users", "users", "CREATE TABLE ...", "name", "TEXT", 0.0, nil, 0.0},
+	}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		n := len(rows)
+		if strings.HasSuffix(req.SQL, " LIMIT 2") {
+			n = 2
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"type", "name", "tbl_name", "sql", "name", "type", "notnull", "dflt_value", "pk"},
+			rows[:n]...)}, nil)
+	}, WithDefaultLimit(2))
+
+	objects, err := client.Schema(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objects) != 1 || len(objects[0].Columns) != 3 {
+		t.Errorf("got %+v, want table users with 3 columns", objects)
+	}
+}
@@ -0,0 +1,104 @@
+package cfd1
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ExecuteScript executes a SQL script on this database, such as a seed or
+// fixture, which may be larger than a single query allows. The script is split
+// into statements, which are grouped into batches that fit within the 100KB
+// query size limit, and the batches are executed in order. Execution stops at
+// the first error, which identifies the statements of the failing batch;
+// batches executed before it are not rolled back. The script cannot use
+// placeholder parameters.
+//
+// Unlike [Handle.Import], which uses the bulk import API and makes the database
+// unavailable while it runs, each batch is an ordinary query.
+func (h *Handle) ExecuteScript(ctx context.Context, script string) error {
+	batches, err := h.client.scriptBatches(ctx, script)
+	if err != nil {
+		return err
+	}
+	for _, b := range batches {
+		if err := h.Execute(ctx, b.sql); err != nil {
+			return fmt.Errorf("executing statements %d to %d of script: %w", b.first, b.last, err)
+		}
+	}
+	return nil
+}
+
+// ExecuteFile reads the SQL script at path and executes it on this database,
+// as described for [Handle.ExecuteScript].
+func (h *Handle) ExecuteFile(ctx context.Context, path string) error {
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return h.ExecuteScript(ctx, string(script))
+}
+
+// ExecuteFS reads the SQL script name from fsys and executes it on this
+// database, as described for [Handle.ExecuteScript]. This allows scripts
+// embedded in the program to be executed:
+//
+//	//go:embed testdata/fixtures.sql
+//	var fixtures embed.FS
+//
+//	err := h.ExecuteFS(ctx, fixtures, "testdata/fixtures.sql")
+func (h *Handle) ExecuteFS(ctx context.Context, fsys fs.FS, name string) error {
+	script, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	return h.ExecuteScript(ctx, string(script))
+}
+
+// scriptBatch is a group of statements from a script that are executed as a
+// single query. first and last are the 1-based numbers of its statements.
+type scriptBatch struct {
+	sql         string
+	first, last int
+}
+
+// scriptBatches splits script into statements and groups consecutive
+// statements into batches that are at most MaxQuerySize bytes as sent by c
+// with ctx, including the clauses and statements that c adds to queries.
+func (c *Client) scriptBatches(ctx context.Context, script string) ([]scriptBatch, error) {
+	var batches []scriptBatch
+	var sb strings.Builder
+	statements := splitStatements(tokenizeSQL(script))
+	limit := MaxQuerySize - c.querySizeOverhead()
+	var first, size int
+	for i, stmt := range statements {
+		last := stmt[len(stmt)-1]
+		text := script[stmt[0].pos : last.pos+len(last.text)]
+		// Each statement is sent with its terminating semicolon
+		if sent, _ := c.sentSQL(ctx, text+";"); len(sent) > MaxQuerySize {
+			return nil, fmt.Errorf("statement %d of script: %w", i+1,
+				&QueryTooLargeError{Size: len(sent), Max: MaxQuerySize})
+		}
+		stmtSize := len(c.defaultLimit(ctx, text)) + 1
+		if sb.Len() > 0 && size+stmtSize+1 > limit {
+			batches = append(batches, scriptBatch{sql: sb.String(), first: first, last: i})
+			sb.Reset()
+			size = 0
+		}
+		if sb.Len() == 0 {
+			first = i + 1
+		} else {
+			sb.WriteString("\n")
+			size++
+		}
+		sb.WriteString(text)
+		sb.WriteString(";")
+		size += stmtSize
+	}
+	if sb.Len() > 0 {
+		batches = append(batches, scriptBatch{sql: sb.String(), first: first, last: len(statements)})
+	}
+	return batches, nil
+}
@@ -0,0 +1,136 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestScriptBatches(t *testing.T) {
+	long := "INSERT INTO t VALUES ('" + strings.Repeat("x", 60_000) + "')"
+
+	tests := []struct {
+		name     string
+		script   string
+		expected []scriptBatch
+		wantErr  bool
+	}{
+		{"Empty", "-- nothing\n", nil, false},
+		{
+			"Single batch",
+			"CREATE TABLE t (a);\n-- seed\nINSERT INTO t VALUES (1);\n",
+			[]scriptBatch{{"CREATE TABLE t (a);\nINSERT INTO t VALUES (1);", 1, 2}},
+			false,
+		},
+		{
+			"Split by size",
+			"SELECT 1; " + long + "; " + long + "; SELECT 2",
+			[]scriptBatch{{"SELECT 1;\n" + long + ";", 1, 2}, {long + ";\nSELECT 2;", 3, 4}},
+			false,
+		},
+		{"Statement too large", "INSERT INTO t VALUES ('" + strings.Repeat("x", MaxQuerySize) + "')", nil, true},
+	}
+	client := NewClient("test-account", "test-token")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.scriptBatches(context.Background(), tt.script)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scriptBatches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %d batches, want %d", len(got), len(tt.expected))
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("batch %d = %+v, want %+v", i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScriptBatchesSize(t *testing.T) {
+	// A statement of exactly MaxQuerySize bytes exceeds the limit once its
+	// semicolon is added, and the error reports that size
+	prefix, suffix := "INSERT INTO t VALUES ('", "')"
+	stmt := prefix + strings.Repeat("x", MaxQuerySize-len(prefix)-len(suffix)) + suffix
+	client := NewClient("test-account", "test-token")
+	_, err := client.scriptBatches(context.Background(), stmt)
+	var tooLarge *QueryTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Size != MaxQuerySize+1 {
+		t.Errorf("expected QueryTooLargeError of %d bytes, got %v", MaxQuerySize+1, err)
+	}
+	if _, err := client.scriptBatches(context.Background(), stmt[:len(stmt)-3]+"')"); err != nil {
+		t.Errorf("unexpected error for a statement that fits: %v", err)
+	}
+}
+
+func TestExecuteScriptQueryOptions(t *testing.T) {
+	// The statements added by implicit transactions and the LIMIT clauses of
+	// the default limit must fit in each batch as sent
+	var sizes []int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sizes = append(sizes, len(req.SQL))
+		writeAPIResult(w, []RawQueryResult{rawResult(nil)}, nil)
+	}, WithImplicitTransactions(), WithDefaultLimit(10))
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	script := strings.Repeat("INSERT INTO t VALUES (1);\nSELECT x FROM t;\n", 5000)
+	if err := h.ExecuteScript(context.Background(), script); err != nil {
+		t.Fatalf("ExecuteScript() error = %v", err)
+	}
+	if len(sizes) < 2 {
+		t.Fatalf("expected multiple batches, got %d", len(sizes))
+	}
+	for i, size := range sizes {
+		if size > MaxQuerySize {
+			t.Errorf("batch %d is %d bytes", i, size)
+		}
+	}
+}
+
+func TestExecuteFS(t *testing.T) {
+	var queries []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ SQL string }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		queries = append(queries, req.SQL)
+		if strings.Contains(req.SQL, "missing") {
+			writeAPIError(w, http.StatusBadRequest, 7500, "no such table: missing: SQLITE_ERROR")
+			return
+		}
+		writeAPIResult(w, []QueryResult{{Success: true}}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	fsys := fstest.MapFS{
+		"seed.sql": {Data: []byte("CREATE TABLE t (a);\nINSERT INTO t VALUES (1);\n")},
+		"bad.sql":  {Data: []byte("DELETE FROM missing;")},
+	}
+
+	if err := h.ExecuteFS(context.Background(), fsys, "seed.sql"); err != nil {
+		t.Fatalf("ExecuteFS() error = %v", err)
+	}
+	if len(queries) != 1 || queries[0] != "CREATE TABLE t (a);\nINSERT INTO t VALUES (1);" {
+		t.Errorf("queries = %q", queries)
+	}
+
+	err := h.ExecuteFS(context.Background(), fsys, "bad.sql")
+	var sqliteErr *SQLiteError
+	if !errors.As(err, &sqliteErr) {
+		t.Errorf("ExecuteFS() error = %v, want SQLiteError", err)
+	}
+
+	if err := h.ExecuteFS(context.Background(), fsys, "absent.sql"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ExecuteFS() error = %v, want fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,427 @@
+package cfd1
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind identifies the kind of an SQL token.
+type tokenKind int
+
+const (
+	tokenWord        tokenKind = iota // keyword or unquoted identifier
+	tokenQuotedIdent                  // "ident", `ident` or [ident]
+	tokenString                       // 'string' or X'blob'
+	tokenNumber                       // numeric literal
+	tokenParam                        // ?, ?NNN, :name, @name or $name
+	tokenPunct                        // operator or punctuation character
+	tokenSemicolon                    // statement terminator
+)
+
+// sqlToken is a single token of an SQL string. Whitespace and comments are not
+// represented as tokens.
+type sqlToken struct {
+	kind tokenKind
+	text string // the token's text, exactly as it appears in the SQL
+	pos  int    // byte offset of the token within the SQL
+}
+
+// is reports whether t is the word or punctuation given by s, ignoring case.
+func (t sqlToken) is(s string) bool {
+	return (t.kind == tokenWord || t.kind == tokenPunct) && strings.EqualFold(t.text, s)
+}
+
+// tokenizeSQL splits sql into tokens following SQLite's lexical rules closely
+// enough to locate statements, keywords, and placeholders. It does not fail on
+// invalid SQL; unterminated strings and comments extend to the end of the input.
+func tokenizeSQL(sql string) []sqlToken {
+	var tokens []sqlToken
+	i := 0
+	for i < len(sql) {
+		c := sql[i]
+		start := i
+		kind := tokenPunct
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+			continue
+
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(sql)
+			}
+			continue
+
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(sql)
+			}
+			continue
+
+		case c == ';':
+			kind = tokenSemicolon
+			i++
+
+		case c == '\'':
+			kind = tokenString
+			i = scanQuoted(sql, i, '\'')
+
+		case (c == 'x' || c == 'X') && i+1 < len(sql) && sql[i+1] == '\'':
+			kind = tokenString
+			i = scanQuoted(sql, i+1, '\'')
+
+		case c == '"' || c == '`':
+			kind = tokenQuotedIdent
+			i = scanQuoted(sql, i, c)
+
+		case c == '[':
+			kind = tokenQuotedIdent
+			if end := strings.IndexByte(sql[i:], ']'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(sql)
+			}
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			kind = tokenNumber
+			i = scanNumber(sql, i)
+
+		case c == '?':
+			kind = tokenParam
+			i++
+			for i < len(sql) && sql[i] >= '0' && sql[i] <= '9' {
+				i++
+			}
+
+		case (c == ':' || c == '@' || c == '$') && i+1 < len(sql) && isIdentRune(sql[i+1:], false):
+			kind = tokenParam
+			i = scanIdent(sql, i+1)
+
+		case isIdentRune(sql[i:], true):
+			kind = tokenWord
+			i = scanIdent(sql, i)
+
+		default:
+			_, size := utf8.DecodeRuneInString(sql[i:])
+			i += size
+		}
+
+		tokens = append(tokens, sqlToken{kind: kind, text: sql[start:i], pos: start})
+	}
+	return tokens
+}
+
+// scanQuoted returns the offset just past the quoted string starting at sql[i],
+// where doubled quote characters are escapes.
+func scanQuoted(sql string, i int, quote byte) int {
+	for i++; i < len(sql); i++ {
+		if sql[i] == quote {
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// scanNumber returns the offset just past the numeric literal starting at
+// sql[i].
+func scanNumber(sql string, i int) int {
+	if strings.HasPrefix(sql[i:], "0x") || strings.HasPrefix(sql[i:], "0X") {
+		for i += 2; i < len(sql) && strings.IndexByte("0123456789abcdefABCDEF", sql[i]) >= 0; i++ {
+		}
+		return i
+	}
+	for i < len(sql) {
+		c := sql[i]
+		switch {
+		case c >= '0' && c <= '9' || c == '.':
+			i++
+		case (c == 'e' || c == 'E') && i+1 < len(sql):
+			i++
+			if sql[i] == '+' || sql[i] == '-' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// scanIdent returns the offset just past the identifier starting at sql[i].
+func scanIdent(sql string, i int) int {
+	for i < len(sql) && isIdentRune(sql[i:], false) {
+		_, size := utf8.DecodeRuneInString(sql[i:])
+		i += size
+	}
+	return i
+}
+
+// isIdentRune reports whether the first rune of s can appear in an unquoted
+// identifier, at its start if first is true.
+func isIdentRune(s string, first bool) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	switch {
+	case r == '_' || r >= 0x80 || unicode.IsLetter(r):
+		return true
+	case r >= '0' && r <= '9' || r == '$':
+		return !first
+	}
+	return false
+}
+
+// unquoteIdent returns the name of the identifier represented by t, removing
+// quotes from quoted identifiers.
+func unquoteIdent(t sqlToken) string {
+	if t.kind != tokenQuotedIdent || len(t.text) < 2 {
+		return t.text
+	}
+	switch q := t.text[0]; q {
+	case '"', '`':
+		return strings.ReplaceAll(t.text[1:len(t.text)-1], string([]byte{q, q}), string(q))
+	}
+	return t.text[1 : len(t.text)-1] // [ident]
+}
+
+// paramNumbers returns, for each token in tokens, the 1-based number of the
+// parameter it binds, or 0 if the token is not a placeholder. Numbers follow
+// SQLite's rules: ?NNN binds parameter NNN, a named parameter binds the same
+// number as earlier uses of the same name, and any other placeholder binds one
+// more than the largest number used so far.
+func paramNumbers(tokens []sqlToken) []int {
+	numbers := make([]int, len(tokens))
+	named := make(map[string]int)
+	largest := 0
+	for i, t := range tokens {
+		if t.kind != tokenParam {
+			continue
+		}
+		switch {
+		case t.text == "?":
+			largest++
+			numbers[i] = largest
+		case t.text[0] == '?':
+			n, _ := strconv.Atoi(t.text[1:])
+			numbers[i] = n
+			largest = max(largest, n)
+		default:
+			if n, ok := named[t.text]; ok {
+				numbers[i] = n
+				continue
+			}
+			largest++
+			named[t.text] = largest
+			numbers[i] = largest
+		}
+	}
+	return numbers
+}
+
+// splitStatements splits tokens into statements at each semicolon, omitting
+// empty statements. The semicolons are not included. Semicolons within the
+// BEGIN ... END body of a CREATE TRIGGER statement do not end the statement.
+func splitStatements(tokens []sqlToken) [][]sqlToken {
+	var statements [][]sqlToken
+	start := 0
+	inBody, caseDepth := false, 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) {
+			t := tokens[i]
+			switch {
+			case !inBody && t.is("BEGIN") && isCreateTrigger(tokens[start:i]):
+				inBody = true
+			case inBody && t.is("CASE"):
+				caseDepth++
+			case inBody && t.is("END"):
+				if caseDepth > 0 {
+					caseDepth--
+				} else {
+					inBody = false
+				}
+			}
+			if inBody || t.kind != tokenSemicolon {
+				continue
+			}
+		}
+		if i > start {
+			statements = append(statements, tokens[start:i])
+		}
+		start = i + 1
+	}
+	return statements
+}
+
+// isCreateTrigger reports whether stmt begins a CREATE TRIGGER statement.
+func isCreateTrigger(stmt []sqlToken) bool {
+	if len(stmt) < 2 || !stmt[0].is("CREATE") {
+		return false
+	}
+	if stmt[1].is("TEMP") || stmt[1].is("TEMPORARY") {
+		return len(stmt) > 2 && stmt[2].is("TRIGGER")
+	}
+	return stmt[1].is("TRIGGER")
+}
+
+// isWriteStatement reports whether stmt may modify the database. SELECT,
+// VALUES, and EXPLAIN statements, WITH statements not containing INSERT,
+// UPDATE, DELETE, or REPLACE, other than the replace() function, and PRAGMA
+// statements classified as read-only by isWritePragma are considered
+// read-only; all other statements are considered writes.
+func isWriteStatement(stmt []sqlToken) bool {
+	if len(stmt) == 0 {
+		return false
+	}
+	switch first := stmt[0]; {
+	case first.is("SELECT"), first.is("VALUES"), first.is("EXPLAIN"):
+		return false
+	case first.is("WITH"):
+		for i, t := range stmt {
+			if t.is("INSERT") || t.is("UPDATE") || t.is("DELETE") {
+				return true
+			}
+			// REPLACE followed by ( is the replace() string function
+			if t.is("REPLACE") && (i+1 == len(stmt) || !stmt[i+1].is("(")) {
+				return true
+			}
+		}
+		return false
+	case first.is("PRAGMA"):
+		return isWritePragma(stmt)
+	}
+	return true
+}
+
+// readOnlyPragmas are the pragmas that take an argument in parentheses to
+// select what they report, rather than to set a value.
+var readOnlyPragmas = map[string]bool{
+	"foreign_key_check": true,
+	"foreign_key_list":  true,
+	"index_info":        true,
+	"index_list":        true,
+	"index_xinfo":       true,
+	"integrity_check":   true,
+	"quick_check":       true,
+	"table_info":        true,
+	"table_list":        true,
+	"table_xinfo":       true,
+}
+
+// writePragmas are the pragmas that change the database when run without an
+// argument.
+var writePragmas = map[string]bool{
+	"incremental_vacuum": true,
+	"optimize":           true,
+	"wal_checkpoint":     true,
+}
+
+// isWritePragma reports whether the PRAGMA statement stmt may modify the
+// database: it assigns a value, with = or in the form name(value), unless the
+// pragma is one of readOnlyPragmas, or it is one of writePragmas.
+func isWritePragma(stmt []sqlToken) bool {
+	name := 1
+	if len(stmt) > 3 && stmt[2].is(".") {
+		name = 3 // schema.name
+	}
+	if name >= len(stmt) {
+		return false
+	}
+	pragma := strings.ToLower(unquoteIdent(stmt[name]))
+	if writePragmas[pragma] {
+		return true
+	}
+	for _, t := range stmt[name+1:] {
+		if t.is("=") || t.is("(") && !readOnlyPragmas[pragma] {
+			return true
+		}
+	}
+	return false
+}
+
+// isReadOnlyQuery reports whether sql contains at least one statement, other
+// than transaction control statements, and none that may modify the database,
+// as classified by isWriteStatement.
+func isReadOnlyQuery(sql string) bool {
+	var reads int
+	for _, stmt := range splitStatements(tokenizeSQL(sql)) {
+		switch {
+		case len(stmt) == 0 || isTransactionStatement(stmt):
+		case isWriteStatement(stmt):
+			return false
+		default:
+			reads++
+		}
+	}
+	return reads > 0
+}
+
+// isAtomicQuery reports whether the statements of sql are committed together
+// or not at all: sql is a single statement other than a transaction control
+// statement, or a BEGIN statement, followed by statements that are not
+// transaction control statements, followed by a COMMIT or END statement.
+func isAtomicQuery(sql string) bool {
+	statements := splitStatements(tokenizeSQL(sql))
+	if len(statements) == 1 {
+		return !isTransactionStatement(statements[0])
+	}
+	if len(statements) < 2 {
+		return false
+	}
+	first, last := statements[0], statements[len(statements)-1]
+	if !first[0].is("BEGIN") || !last[0].is("COMMIT") && !last[0].is("END") {
+		return false
+	}
+	for _, stmt := range statements[1 : len(statements)-1] {
+		if isTransactionStatement(stmt) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTransactionStatement reports whether stmt is a transaction control
+// statement, such as BEGIN, COMMIT, or SAVEPOINT.
+func isTransactionStatement(stmt []sqlToken) bool {
+	if len(stmt) == 0 {
+		return false
+	}
+	for _, keyword := range []string{"BEGIN", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE"} {
+		if stmt[0].is(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSQL returns the shape of sql, in which literals and placeholders
+// are replaced with ?, keywords are uppercased, and whitespace and comments are
+// normalized, so that queries differing only in their values have the same
+// shape. Lists of values, such as IN (?, ?, ?), are collapsed to a single ?.
+func normalizeSQL(sql string) string {
+	var parts []string
+	for _, t := range tokenizeSQL(sql) {
+		part := t.text
+		switch t.kind {
+		case tokenString, tokenNumber, tokenParam:
+			n := len(parts)
+			if n >= 2 && parts[n-1] == "," && parts[n-2] == "?" {
+				parts = parts[:n-1]
+				continue
+			}
+			part = "?"
+		case tokenWord:
+			part = strings.ToUpper(part)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,197 @@
+package cfd1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{"Simple select", "SELECT * FROM t", []string{"SELECT", "*", "FROM", "t"}},
+		{"Strings and comments", "SELECT 'a;b''c' -- x;\n/* y; */ FROM t;", []string{"SELECT", "'a;b''c'", "FROM", "t", ";"}},
+		{"Quoted identifiers", "SELECT \"a\"\"b\", `c`, [d e] FROM t", []string{"SELECT", "\"a\"\"b\"", ",", "`c`", ",", "[d e]", "FROM", "t"}},
+		{"Placeholders", "VALUES (?, ?12, :name, @id, $v)", []string{"VALUES", "(", "?", ",", "?12", ",", ":name", ",", "@id", ",", "$v", ")"}},
+		{"Numbers and blobs", "SELECT 1.5e-3, 0xFF, X'00ff', .5", []string{"SELECT", "1.5e-3", ",", "0xFF", ",", "X'00ff'", ",", ".5"}},
+		{"Unterminated string", "SELECT 'abc", []string{"SELECT", "'abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, tok := range tokenizeSQL(tt.sql) {
+				got = append(got, tok.text)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("unexpected tokens: got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParamNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []int
+	}{
+		{"Anonymous", "SELECT ?, ?, ?", []int{1, 2, 3}},
+		{"Numbered", "SELECT ?2, ?1, ?", []int{2, 1, 3}},
+		{"Named reuse", "SELECT :a, :b, :a, ?", []int{1, 2, 1, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := tokenizeSQL(tt.sql)
+			var got []int
+			for _, n := range paramNumbers(tokens) {
+				if n > 0 {
+					got = append(got, n)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("unexpected numbers: got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBindingHints(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		message  string
+		expected []int
+	}{
+		{"Insert", "INSERT INTO users (name, email) VALUES (?, ?)", "UNIQUE constraint failed: users.email", []int{1}},
+		{"Multi-row insert", "INSERT INTO users (name, email) VALUES (?, ?), (?, ?)", "UNIQUE constraint failed: users.email", []int{1, 3}},
+		{"Quoted column", `INSERT INTO users ("id", "name") VALUES (?, lower(?))`, "NOT NULL constraint failed: users.id", []int{0}},
+		{"Update", "UPDATE users SET email = ? WHERE id = ?", "UNIQUE constraint failed: users.email", []int{0}},
+		{"Composite", "INSERT INTO t (a, b, c) VALUES (?, ?, ?)", "UNIQUE constraint failed: t.a, t.c", []int{0, 2}},
+		{"No column", "INSERT INTO t (a) VALUES (?)", "FOREIGN KEY constraint failed", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newSQLiteError(tt.message, tt.query, []any{"a", "b", "c", "d"}, "SQLITE_CONSTRAINT")
+			e.addBindingHints()
+			if !reflect.DeepEqual(e.RelatedBindings, tt.expected) {
+				t.Errorf("unexpected bindings: got %v, want %v (%v)", e.RelatedBindings, tt.expected, e)
+			}
+		})
+	}
+}
+
+func TestIsWriteStatement(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected bool
+	}{
+		{"SELECT * FROM t", false},
+		{"  select 1", false},
+		{"VALUES (1), (2)", false},
+		{"EXPLAIN QUERY PLAN DELETE FROM t", false},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", false},
+		{"WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x", true},
+		{"WITH x AS (SELECT a FROM t) SELECT replace(a, 'b', 'c') FROM x", false},
+		{"WITH x AS (SELECT 1) REPLACE INTO t SELECT * FROM x", true},
+		{"PRAGMA table_info(t)", false},
+		{"PRAGMA foreign_keys = ON", true},
+		{"PRAGMA main.index_list(t)", false},
+		{"PRAGMA integrity_check(10)", false},
+		{"PRAGMA user_version", false},
+		{"PRAGMA user_version(5)", true},
+		{"PRAGMA main.user_version(5)", true},
+		{"PRAGMA optimize", true},
+		{"PRAGMA wal_checkpoint(TRUNCATE)", true},
+		{"PRAGMA main.incremental_vacuum", true},
+		{"INSERT INTO t VALUES (1)", true},
+		{"CREATE TABLE t (a)", true},
+		{"-- comment\nDELETE FROM t", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			if got := isWriteStatement(tokenizeSQL(tt.sql)); got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected bool
+	}{
+		{"SELECT 1", true},
+		{"SELECT 1; PRAGMA table_info(t); EXPLAIN DELETE FROM t", true},
+		{"BEGIN; SELECT 1; COMMIT", true},
+		{"SELECT 1; UPDATE t SET a = 1", false},
+		{"INSERT INTO t SELECT 1", false},
+		{"BEGIN; COMMIT", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			if got := isReadOnlyQuery(tt.sql); got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{"Simple", "SELECT 1; SELECT 2;", []string{"SELECT 1", "SELECT 2"}},
+		{"Empty statements", ";; SELECT 1;;", []string{"SELECT 1"}},
+		{"Semicolon in string", "SELECT ';'; SELECT 2", []string{"SELECT ';'", "SELECT 2"}},
+		{
+			"Trigger",
+			"CREATE TRIGGER t AFTER INSERT ON a BEGIN UPDATE b SET n = CASE WHEN n > 0 THEN n ELSE 0 END; DELETE FROM c; END; SELECT 1",
+			[]string{"CREATE TRIGGER t AFTER INSERT ON a BEGIN UPDATE b SET n = CASE WHEN n > 0 THEN n ELSE 0 END; DELETE FROM c; END", "SELECT 1"},
+		},
+		{"Transaction", "BEGIN; INSERT INTO t VALUES (1); END;", []string{"BEGIN", "INSERT INTO t VALUES (1)", "END"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, stmt := range splitStatements(tokenizeSQL(tt.sql)) {
+				last := stmt[len(stmt)-1]
+				got = append(got, tt.sql[stmt[0].pos:last.pos+len(last.text)])
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{"Literals", "select * from users where id = 42 and name = 'bob'", "SELECT * FROM USERS WHERE ID = ? AND NAME = ?"},
+		{"Placeholders", "SELECT * FROM users WHERE id = ?1 OR id = :id", "SELECT * FROM USERS WHERE ID = ? OR ID = ?"},
+		{"Whitespace and comments", "SELECT  *\n FROM users -- all\nWHERE id=?", "SELECT * FROM USERS WHERE ID = ?"},
+		{"IN list", "SELECT * FROM t WHERE id IN (1, 2, 3)", "SELECT * FROM T WHERE ID IN ( ? )"},
+		{"Quoted identifier", `SELECT "Name" FROM t`, `SELECT "Name" FROM T`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSQL(tt.sql); got != tt.expected {
+				t.Errorf("normalizeSQL() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
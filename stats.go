@@ -0,0 +1,63 @@
+package cfd1
+
+import "net/http/httptrace"
+
+// ClientStats is a snapshot of a client's counters, returned by
+// [Client.Stats]. The counters accumulate from the client's creation, or the
+// last call to [Client.ResetCounters].
+type ClientStats struct {
+	RowsRead    int // rows read by queries; see [Client.RowsRead]
+	RowsWritten int // rows written by queries; see [Client.RowsWritten]
+
+	// NewConns and ReusedConns count the HTTP requests that opened a new
+	// connection to the API, and those that reused a pooled one. They are only
+	// counted for clients created with [WithConnStats].
+	NewConns    int
+	ReusedConns int
+}
+
+// ConnReuseRate returns the fraction of HTTP requests that reused a pooled
+// connection, from 0 to 1, or 0 if no connections were counted. A low rate
+// under steady load means that connections are often closed while idle, and
+// that requests pay for a new TCP connection and TLS handshake; raising the
+// idle connection limits of the client's HTTP transport may help.
+func (s ClientStats) ConnReuseRate() float64 {
+	total := s.NewConns + s.ReusedConns
+	if total == 0 {
+		return 0
+	}
+	return float64(s.ReusedConns) / float64(total)
+}
+
+// WithConnStats enables counting whether each HTTP request made by the client
+// opened a new connection or reused a pooled one, using [httptrace]. The
+// counts are reported by [Client.Stats].
+func WithConnStats() ClientOption {
+	return func(c *Client) {
+		c.connStats = true
+	}
+}
+
+// Stats returns a snapshot of the client's counters.
+func (c *Client) Stats() ClientStats {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return ClientStats{
+		RowsRead:    c.rowsRead,
+		RowsWritten: c.rowsWritten,
+		NewConns:    c.newConns,
+		ReusedConns: c.reusedConns,
+	}
+}
+
+// countConn counts the connection obtained for an HTTP request. It is called by
+// the request's [httptrace.ClientTrace].
+func (c *Client) countConn(info httptrace.GotConnInfo) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if info.Reused {
+		c.reusedConns++
+	} else {
+		c.newConns++
+	}
+}
@@ -0,0 +1,50 @@
+package cfd1
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestConnStats(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		rs := rawResult([]string{"x"}, []any{1.0})
+		rs.Meta.RowsRead = 1
+		writeAPIResult(w, []RawQueryResult{rs}, nil)
+	}
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+		want ClientStats
+	}{
+		{"Disabled", nil, ClientStats{RowsRead: 3}},
+		{"Enabled", []ClientOption{WithConnStats()}, ClientStats{RowsRead: 3, NewConns: 1, ReusedConns: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, handler, tt.opts...)
+			for range 3 {
+				if _, err := client.Query(context.Background(), "db", "SELECT 1 AS x"); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if got := client.Stats(); got != tt.want {
+				t.Errorf("unexpected stats: got %+v, want %+v", got, tt.want)
+			}
+
+			client.ResetCounters()
+			if got := client.Stats(); got != (ClientStats{}) {
+				t.Errorf("unexpected stats after reset: %+v", got)
+			}
+		})
+	}
+
+	s := ClientStats{NewConns: 1, ReusedConns: 3}
+	if got := s.ConnReuseRate(); got != 0.75 {
+		t.Errorf("unexpected reuse rate: got %v, want 0.75", got)
+	}
+	if got := (ClientStats{}).ConnReuseRate(); got != 0 {
+		t.Errorf("unexpected reuse rate with no connections: got %v, want 0", got)
+	}
+}
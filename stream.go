@@ -0,0 +1,165 @@
+package cfd1
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+)
+
+// defaultStreamPageSize is the number of rows that [Handle.QueryStream] and
+// [Handle.QueryStreamKeyset] request in each page, by default.
+const defaultStreamPageSize = 1000
+
+// WithStreamPageSize sets the number of rows that [Handle.QueryStream] and
+// [Handle.QueryStreamKeyset] request in each page. The default is 1000. Larger
+// pages need fewer requests, but hold more rows in memory at once. If the
+// client was created with [WithMaxResultRows], pages are no larger than its
+// maximum.
+func WithStreamPageSize(n int) ClientOption {
+	return func(c *Client) {
+		c.streamPageRows = n
+	}
+}
+
+// QueryStream executes a SQL query on this database a page at a time, and
+// returns an iterator over its rows, so that large results can be processed
+// without holding every row in memory. D1 has no server-side cursors, so if
+// the query is a single SELECT, WITH, or VALUES statement without a LIMIT
+// clause of its own, a LIMIT and OFFSET clause is appended to it for each
+// page, and the pages are requested one after another as the rows are
+// consumed, until one has fewer rows than the page size set with
+// [WithStreamPageSize]. Any other query is executed once, and its rows are
+// returned from the single response.
+//
+// Each page is a separate request, and the query is not run in a transaction,
+// so rows that are inserted, deleted, or updated by other clients while the
+// rows are read can be skipped or returned twice. The query should have an
+// ORDER BY clause over unique columns, as the order of rows, and so the
+// contents of each page, is otherwise undefined. SQLite reads and discards the
+// rows skipped by OFFSET, so the rows read by later pages, and so the cost of
+// the query, grow with the size of the result; [Handle.QueryStreamKeyset]
+// avoids both problems for queries that can be ordered by a unique key.
+//
+// The rewritten queries are the ones checked against the client's
+// [WithQueryAllowlist], if any. If a page fails, the iterator yields the error
+// and stops.
+//
+// Example usage:
+//
+//	for row, err := range h.QueryStream(ctx, "SELECT * FROM events ORDER BY id") {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    process(row)
+//	}
+func (h *Handle) QueryStream(ctx context.Context, sql string, params ...any) iter.Seq2[map[string]any, error] {
+	return h.stream(ctx, sql, params, func(query string, n, offset int, _ map[string]any) (string, error) {
+		return query + " LIMIT " + strconv.Itoa(n) + " OFFSET " + strconv.Itoa(offset), nil
+	})
+}
+
+// QueryStreamKeyset executes a SQL query on this database a page at a time,
+// like [Handle.QueryStream], but pages through its rows in order of the key
+// column rather than by OFFSET. The query is wrapped in a SELECT that orders
+// its rows by key and selects those after the last key of the previous page,
+// so each page reads only its own rows, and rows are not skipped or repeated
+// when rows of earlier pages are inserted or deleted.
+//
+// The key column must be part of the query's result, and be unique and not
+// NULL in every row; rows with a NULL key are omitted after the first page.
+// Rows are returned in order of the key, regardless of any ORDER BY clause of
+// the query. As each page is still a separate request, rows that change while
+// the rows are read may be returned in either their old or new state.
+func (h *Handle) QueryStreamKeyset(ctx context.Context, key string, sql string, params ...any) iter.Seq2[map[string]any, error] {
+	column := QuoteIdentifier(key)
+	return h.stream(ctx, sql, params, func(query string, n, _ int, last map[string]any) (string, error) {
+		where := ""
+		if last != nil {
+			value, ok := last[key]
+			if !ok {
+				return "", fmt.Errorf("key column %q is not in the query's result", key)
+			}
+			if value == nil {
+				return "", fmt.Errorf("key column %q is NULL", key)
+			}
+			values, err := convertTypes([]any{value})
+			if err != nil {
+				return "", err
+			}
+			literal, err := sqlLiteral(values[0])
+			if err != nil {
+				return "", fmt.Errorf("key column %q: %w", key, err)
+			}
+			where = " WHERE " + column + " > " + literal
+		}
+		return "SELECT * FROM (" + query + ")" + where + " ORDER BY " + column + " LIMIT " + strconv.Itoa(n), nil
+	})
+}
+
+// pageQueryFunc returns the query for a page of n rows of query, which starts
+// at offset and follows the row last, which is nil for the first page.
+type pageQueryFunc func(query string, n, offset int, last map[string]any) (string, error)
+
+// stream returns an iterator over the rows of sql, requested a page at a time
+// with the queries returned by page if sql can be paged, and at once otherwise.
+func (h *Handle) stream(ctx context.Context, sql string, params []any, page pageQueryFunc) iter.Seq2[map[string]any, error] {
+	return func(yield func(map[string]any, error) bool) {
+		statements := splitStatements(tokenizeSQL(sql))
+		end, ok := 0, false
+		if len(statements) == 1 {
+			end, ok = limitPosition(statements[0])
+		}
+		if !ok {
+			result, err := h.query(ctx, sql, params...)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, row := range result.Results {
+				if !yield(row, nil) {
+					return
+				}
+			}
+			return
+		}
+
+		query := sql[statements[0][0].pos:end]
+		n := h.client.streamPageSize()
+		var last map[string]any
+		for offset := 0; ; offset += n {
+			pageSQL, err := page(query, n, offset, last)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			result, err := h.query(ctx, pageSQL, params...)
+			if err != nil {
+				yield(nil, fmt.Errorf("reading rows from %d: %w", offset, err))
+				return
+			}
+			for _, row := range result.Results {
+				if !yield(row, nil) {
+					return
+				}
+			}
+			if len(result.Results) < n {
+				return
+			}
+			last = result.Results[len(result.Results)-1]
+		}
+	}
+}
+
+// streamPageSize returns the number of rows to request in each page of a
+// streamed query.
+func (c *Client) streamPageSize() int {
+	n := c.streamPageRows
+	if n <= 0 {
+		n = defaultStreamPageSize
+	}
+	if c.maxResultRows > 0 {
+		n = min(n, c.maxResultRows)
+	}
+	return n
+}
@@ -0,0 +1,147 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestQueryStream(t *testing.T) {
+	pageRE := regexp.MustCompile(`(?:> (\d+) ORDER BY "id" )?LIMIT (\d+)(?: OFFSET (\d+))?$`)
+	const table = 5
+	tests := []struct {
+		name    string
+		keyset  bool
+		sql     string
+		opts    []ClientOption
+		want    int // number of rows read
+		stop    int // number of rows after which to stop, or 0
+		wantSQL []string
+	}{
+		{
+			name: "Offset",
+			sql:  "SELECT id FROM t ORDER BY id;",
+			want: 5,
+			wantSQL: []string{
+				"SELECT id FROM t ORDER BY id LIMIT 2 OFFSET 0",
+				"SELECT id FROM t ORDER BY id LIMIT 2 OFFSET 2",
+				"SELECT id FROM t ORDER BY id LIMIT 2 OFFSET 4",
+			},
+		},
+		{
+			name:   "Keyset",
+			keyset: true,
+			sql:    "SELECT id FROM t WHERE id > ?",
+			want:   5,
+			wantSQL: []string{
+				`SELECT * FROM (SELECT id FROM t WHERE id > ?) ORDER BY "id" LIMIT 2`,
+				`SELECT * FROM (SELECT id FROM t WHERE id > ?) WHERE "id" > 2 ORDER BY "id" LIMIT 2`,
+				`SELECT * FROM (SELECT id FROM t WHERE id > ?) WHERE "id" > 4 ORDER BY "id" LIMIT 2`,
+			},
+		},
+		{
+			name:    "Stop early",
+			sql:     "SELECT id FROM t",
+			stop:    3,
+			want:    3,
+			wantSQL: []string{"SELECT id FROM t LIMIT 2 OFFSET 0", "SELECT id FROM t LIMIT 2 OFFSET 2"},
+		},
+		{
+			name:    "Existing limit",
+			sql:     "SELECT id FROM t LIMIT 10",
+			want:    5,
+			wantSQL: []string{"SELECT id FROM t LIMIT 10"},
+		},
+		{
+			name:    "Max result rows",
+			sql:     "SELECT id FROM t",
+			opts:    []ClientOption{WithMaxResultRows(4), WithStreamPageSize(10)},
+			want:    5,
+			wantSQL: []string{"SELECT id FROM t LIMIT 4 OFFSET 0", "SELECT id FROM t LIMIT 4 OFFSET 4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			opts := append([]ClientOption{WithStreamPageSize(2)}, tt.opts...)
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				var req rawQueryRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				sent = append(sent, req.SQL)
+				from, n := 0, table
+				if m := pageRE.FindStringSubmatch(req.SQL); m != nil {
+					from, _ = strconv.Atoi(m[1] + m[3])
+					n, _ = strconv.Atoi(m[2])
+				}
+				var rows [][]any
+				for id := from + 1; id <= min(from+n, table); id++ {
+					rows = append(rows, []any{id})
+				}
+				writeAPIResult(w, []RawQueryResult{rawResult([]string{"id"}, rows...)}, nil)
+			}, opts...)
+			h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+			seq := h.QueryStream(context.Background(), tt.sql)
+			if tt.keyset {
+				seq = h.QueryStreamKeyset(context.Background(), "id", tt.sql, 0)
+			}
+			var got int
+			for row, err := range seq {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got++
+				if row["id"] != int64(got) {
+					t.Errorf("row %d: got id %v", got, row["id"])
+				}
+				if got == tt.stop {
+					break
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got %d rows, want %d", got, tt.want)
+			}
+			if !reflect.DeepEqual(sent, tt.wantSQL) {
+				t.Errorf("sent queries:\n%q\nwant:\n%q", sent, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestQueryStreamErrors(t *testing.T) {
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			writeAPIError(w, http.StatusBadRequest, 7500, "no such table: t: SQLITE_ERROR")
+			return
+		}
+		writeAPIResult(w, []RawQueryResult{rawResult([]string{"x"}, []any{1}, []any{2})}, nil)
+	}, WithStreamPageSize(2))
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	var rows int
+	var err error
+	for _, err = range h.QueryStream(context.Background(), "SELECT x FROM t") {
+		if err == nil {
+			rows++
+		}
+	}
+	var sqliteErr *SQLiteError
+	if rows != 2 || !errors.As(err, &sqliteErr) {
+		t.Errorf("got %d rows and error %v, want 2 rows and SQLiteError", rows, err)
+	}
+
+	// The key column must be in the result to request the next page
+	requests = 0
+	for _, err = range h.QueryStreamKeyset(context.Background(), "id", "SELECT x FROM t") {
+	}
+	if err == nil || requests != 1 {
+		t.Errorf("got error %v after %d requests, want missing key error after 1", err, requests)
+	}
+}
@@ -0,0 +1,55 @@
+package cfd1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// templateIdentRegex matches an identifier placeholder in a query template,
+// such as {{.table}}.
+var templateIdentRegex = regexp.MustCompile(`\{\{\s*\.(\w+)\s*\}\}`)
+
+// QueryTemplate executes a query built from template, in which identifiers
+// such as table and column names, which cannot be bound as parameters, are
+// given as placeholders of the form {{.name}}. Each placeholder is replaced
+// with idents[name], quoted with [QuoteIdentifier], while value placeholders,
+// such as ?, remain bound to params as with [Handle.Query].
+//
+// Every placeholder must have an entry in idents, and each identifier must be
+// non-empty valid UTF-8 without NUL characters; otherwise an error is returned
+// and no query is executed. Since the identifiers are quoted, they are matched
+// exactly, including case, and cannot inject SQL.
+//
+// Example usage:
+//
+//	rows, err := h.QueryTemplate(ctx, "SELECT * FROM {{.table}} WHERE user_id = ?",
+//	    map[string]string{"table": "events_" + day.Format("20060102")}, userID)
+func (h *Handle) QueryTemplate(ctx context.Context, template string, idents map[string]string, params ...any) ([]map[string]any, error) {
+	sql, err := expandTemplate(template, idents)
+	if err != nil {
+		return nil, err
+	}
+	return h.Query(ctx, sql, params...)
+}
+
+// expandTemplate replaces the identifier placeholders in template with the
+// quoted identifiers in idents.
+func expandTemplate(template string, idents map[string]string) (string, error) {
+	var errs []error
+	sql := templateIdentRegex.ReplaceAllStringFunc(template, func(match string) string {
+		name := templateIdentRegex.FindStringSubmatch(match)[1]
+		ident, ok := idents[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("no identifier given for template placeholder %q", name))
+		} else if err := checkIdentifiers(ident); err != nil {
+			errs = append(errs, fmt.Errorf("template placeholder %q: %w", name, err))
+		}
+		return QuoteIdentifier(ident)
+	})
+	if err := errors.Join(errs...); err != nil {
+		return "", err
+	}
+	return sql, nil
+}
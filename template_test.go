@@ -0,0 +1,67 @@
+package cfd1
+
+import "testing"
+
+func TestExpandTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		idents   map[string]string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Table and column",
+			template: "SELECT {{.col}} FROM {{ .table }} WHERE id = ?",
+			idents:   map[string]string{"table": "events_20240101", "col": "name"},
+			expected: `SELECT "name" FROM "events_20240101" WHERE id = ?`,
+		},
+		{
+			name:     "Repeated placeholder",
+			template: "SELECT {{.t}}.a FROM {{.t}}",
+			idents:   map[string]string{"t": "x"},
+			expected: `SELECT "x".a FROM "x"`,
+		},
+		{
+			name:     "Injection is quoted",
+			template: "DELETE FROM {{.table}}",
+			idents:   map[string]string{"table": `t"; DROP TABLE users; --`},
+			expected: `DELETE FROM "t""; DROP TABLE users; --"`,
+		},
+		{
+			name:     "No placeholders",
+			template: "SELECT 1",
+			expected: "SELECT 1",
+		},
+		{
+			name:     "Missing identifier",
+			template: "SELECT * FROM {{.table}}",
+			idents:   map[string]string{},
+			wantErr:  true,
+		},
+		{
+			name:     "Empty identifier",
+			template: "SELECT * FROM {{.table}}",
+			idents:   map[string]string{"table": ""},
+			wantErr:  true,
+		},
+		{
+			name:     "NUL in identifier",
+			template: "SELECT * FROM {{.table}}",
+			idents:   map[string]string{"table": "a\x00b"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandTemplate(tt.template, tt.idents)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("expandTemplate() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
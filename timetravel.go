@@ -0,0 +1,184 @@
+package cfd1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RestoreResult describes the outcome of restoring a database with Time
+// Travel.
+type RestoreResult struct {
+	Bookmark         string `json:"bookmark"`          // Bookmark of the restored state
+	PreviousBookmark string `json:"previous_bookmark"` // Bookmark of the state before the restore, to undo it
+	Message          string `json:"message"`
+}
+
+// GetBookmark returns the Time Travel bookmark identifying the state of a
+// database at time t, which can later be passed to [Client.RestoreToBookmark].
+// If t is the zero time, the database's current bookmark is returned. Time
+// Travel retains history for a limited period, 30 days on the paid plan, so
+// older times return an error.
+func (c *Client) GetBookmark(ctx context.Context, databaseID string, t time.Time) (string, error) {
+	path := fmt.Sprintf("/database/%s/time_travel/bookmark", databaseID)
+	if !t.IsZero() {
+		path += "?" + url.Values{"timestamp": {t.UTC().Format(time.RFC3339)}}.Encode()
+	}
+
+	var result struct {
+		Bookmark string `json:"bookmark"`
+	}
+	if err := c.sendRequest(ctx, http.MethodGet, path, nil, &result, nil); err != nil {
+		return "", fmt.Errorf("getting bookmark: %w", err)
+	}
+	return result.Bookmark, nil
+}
+
+// maxListBookmarks is the maximum number of times ListBookmarks requests a
+// bookmark for.
+const maxListBookmarks = 1000
+
+// BookmarkInfo is a Time Travel restore point returned by
+// [Client.ListBookmarks].
+type BookmarkInfo struct {
+	Time     time.Time // Earliest sampled time at which the database was in this state
+	Bookmark string    // Bookmark identifying the state, for Client.RestoreToBookmark
+}
+
+// ListBookmarks returns the restore points of a database between from and to,
+// by requesting the Time Travel bookmark at from and at each interval after
+// it, up to and including to. If to is the zero time, the current time is
+// used. A bookmark that is the same as the previous one, because the database
+// was not changed in between, is omitted, so each returned restore point is a
+// distinct state, identified by the earliest time it was sampled at.
+//
+// D1 does not provide a list of backups or versions: Time Travel can restore a
+// database to any point within its retention window, 30 days on the paid plan
+// and 7 days on the free plan, and the API returns the bookmark for a single
+// time per request. ListBookmarks therefore makes one API request per
+// interval, and returns an error if that would exceed 1000 requests. Times
+// before the retention window cause an error from the API. The API does not
+// report the size of the database at each restore point.
+func (c *Client) ListBookmarks(ctx context.Context, databaseID string, from, to time.Time, interval time.Duration) ([]BookmarkInfo, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("listing bookmarks: interval must be positive")
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("listing bookmarks: end time is before start time")
+	}
+	if n := to.Sub(from)/interval + 1; n > maxListBookmarks {
+		return nil, fmt.Errorf("listing bookmarks: %d intervals requested, maximum is %d", n, maxListBookmarks)
+	}
+
+	var bookmarks []BookmarkInfo
+	for t := from; !t.After(to); t = t.Add(interval) {
+		bookmark, err := c.GetBookmark(ctx, databaseID, t)
+		if err != nil {
+			return nil, fmt.Errorf("listing bookmarks at %s: %w", t.UTC().Format(time.RFC3339), err)
+		}
+		if len(bookmarks) == 0 || bookmarks[len(bookmarks)-1].Bookmark != bookmark {
+			bookmarks = append(bookmarks, BookmarkInfo{Time: t, Bookmark: bookmark})
+		}
+	}
+	return bookmarks, nil
+}
+
+// RestoreToBookmark restores a database to the state identified by bookmark,
+// using Time Travel. All changes made after the bookmark are undone. The
+// returned [RestoreResult] includes the bookmark of the state before the
+// restore, which can be used to undo the restore itself.
+func (c *Client) RestoreToBookmark(ctx context.Context, databaseID, bookmark string) (*RestoreResult, error) {
+	path := fmt.Sprintf("/database/%s/time_travel/restore?%s",
+		databaseID, url.Values{"bookmark": {bookmark}}.Encode())
+
+	var result RestoreResult
+	err := c.sendRequest(ctx, http.MethodPost, path, nil, &result, nil)
+	c.detailsCache.invalidate(databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("restoring to bookmark: %w", err)
+	}
+	return &result, nil
+}
+
+// CurrentBookmark returns the Time Travel bookmark identifying the current
+// state of this database, without performing a restore. Recording it before a
+// risky change, such as a deployment with migrations, provides a restore point
+// for [Handle.RestoreToBookmark].
+//
+// Example usage:
+//
+//	bookmark, err := h.CurrentBookmark(ctx)
+//	if err != nil {
+//	    // handle error
+//	}
+//	// ... deploy; if it goes wrong:
+//	_, err = h.RestoreToBookmark(ctx, bookmark)
+func (h *Handle) CurrentBookmark(ctx context.Context) (string, error) {
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+	return h.client.GetBookmark(ctx, h.dbID, time.Time{})
+}
+
+// RestoreToBookmark restores this database to the state identified by
+// bookmark. See [Client.RestoreToBookmark].
+func (h *Handle) RestoreToBookmark(ctx context.Context, bookmark string) (*RestoreResult, error) {
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+	if h.readOnly {
+		return nil, fmt.Errorf("restoring to bookmark: %w", ErrReadOnly)
+	}
+	return h.client.RestoreToBookmark(ctx, h.dbID, bookmark)
+}
+
+// BackupInfo describes a restore point created by [Handle.CreateBackup].
+type BackupInfo struct {
+	ID        string    // Time Travel bookmark, for Handle.RestoreBackup
+	CreatedAt time.Time // When the backup was created
+	FileSize  int       // Size of the database in bytes when the backup was created
+	NumTables int       // Number of tables when the backup was created
+}
+
+// CreateBackup records a restore point for this database, which can later be
+// passed to [Handle.RestoreBackup], along with the database's size and number
+// of tables at the time.
+//
+// D1 does not offer on-demand backups separate from SQL exports. Instead, Time
+// Travel continuously retains the history of every database, so a backup is a
+// bookmark of its current state, and creating or restoring one takes a single
+// request regardless of the database's size. Bookmarks can only be restored
+// within the Time Travel retention window, 30 days on the paid plan and 7 days
+// on the free plan, and only into the same database. For longer retention, or
+// to copy a database, use [Handle.Export] and [Handle.Import] instead.
+func (h *Handle) CreateBackup(ctx context.Context) (*BackupInfo, error) {
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+	createdAt := time.Now()
+	bookmark, err := h.client.GetBookmark(ctx, h.dbID, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("creating backup: %w", err)
+	}
+	details, err := h.client.GetDatabase(ForceRefresh(ctx), h.dbID)
+	if err != nil {
+		return nil, fmt.Errorf("creating backup: %w", err)
+	}
+	return &BackupInfo{
+		ID:        bookmark,
+		CreatedAt: createdAt,
+		FileSize:  details.FileSize,
+		NumTables: details.NumTables,
+	}, nil
+}
+
+// RestoreBackup restores this database to the state recorded by
+// [Handle.CreateBackup] with the given ID, undoing all changes made since. It
+// is equivalent to [Handle.RestoreToBookmark], which also returns a bookmark
+// that can be used to undo the restore.
+func (h *Handle) RestoreBackup(ctx context.Context, backupID string) error {
+	_, err := h.RestoreToBookmark(ctx, backupID)
+	return err
+}
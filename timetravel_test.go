@@ -0,0 +1,91 @@
+package cfd1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBookmarks(t *testing.T) {
+	const dbPath = "/accounts/test-account/d1/database/e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f"
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == dbPath+"/time_travel/bookmark":
+			bookmark := "current"
+			if ts := r.URL.Query().Get("timestamp"); ts != "" {
+				bookmark = "at-" + ts
+			}
+			writeAPIResult(w, map[string]string{"bookmark": bookmark}, nil)
+		case r.Method == http.MethodPost && r.URL.Path == dbPath+"/time_travel/restore":
+			writeAPIResult(w, RestoreResult{
+				Bookmark:         r.URL.Query().Get("bookmark"),
+				PreviousBookmark: "current",
+			}, nil)
+		default:
+			writeAPIResult(w, DatabaseDetails{UUID: "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f", FileSize: 4096, NumTables: 3}, nil)
+		}
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	bookmark, err := h.CurrentBookmark(context.Background())
+	if err != nil || bookmark != "current" {
+		t.Errorf("CurrentBookmark: got (%q, %v)", bookmark, err)
+	}
+
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	bookmark, err = client.GetBookmark(context.Background(), h.UUID(), at)
+	if err != nil || bookmark != "at-2026-10-01T12:00:00Z" {
+		t.Errorf("GetBookmark: got (%q, %v)", bookmark, err)
+	}
+
+	result, err := h.RestoreToBookmark(context.Background(), "b1")
+	if err != nil || result.Bookmark != "b1" || result.PreviousBookmark != "current" {
+		t.Errorf("RestoreToBookmark: got (%+v, %v)", result, err)
+	}
+
+	backup, err := h.CreateBackup(context.Background())
+	if err != nil || backup.ID != "current" || backup.FileSize != 4096 || backup.NumTables != 3 || backup.CreatedAt.IsZero() {
+		t.Errorf("CreateBackup: got (%+v, %v)", backup, err)
+	}
+	if err := h.RestoreBackup(context.Background(), backup.ID); err != nil {
+		t.Errorf("RestoreBackup: %v", err)
+	}
+}
+
+func TestListBookmarks(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		ts, err := time.Parse(time.RFC3339, r.URL.Query().Get("timestamp"))
+		if err != nil {
+			t.Errorf("invalid timestamp: %v", err)
+		}
+		// The database changes every 2 hours.
+		bookmark := fmt.Sprintf("b%d", int(ts.Sub(start).Hours())/2)
+		writeAPIResult(w, map[string]string{"bookmark": bookmark}, nil)
+	})
+
+	got, err := client.ListBookmarks(context.Background(), "db", start, start.Add(5*time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []BookmarkInfo{
+		{start, "b0"},
+		{start.Add(2 * time.Hour), "b1"},
+		{start.Add(4 * time.Hour), "b2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListBookmarks() = %v, want %v", got, want)
+	}
+	if requests != 6 {
+		t.Errorf("made %d requests, want 6", requests)
+	}
+
+	if _, err := client.ListBookmarks(context.Background(), "db", start, start.Add(30*24*time.Hour), time.Minute); err == nil {
+		t.Error("expected error for too many intervals")
+	}
+}
@@ -0,0 +1,75 @@
+package cfd1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatementTiming describes the execution of a single statement of a query, as
+// reported by the D1 API in its [QueryMeta].
+type StatementTiming struct {
+	Index       int // 0-based index of the statement's result
+	Duration    time.Duration
+	RowsRead    int
+	RowsWritten int
+}
+
+// TimingReport breaks down the execution of a query of several statements by
+// statement, to help find the statements that dominate its cost.
+type TimingReport struct {
+	Statements []StatementTiming
+	Total      StatementTiming // Durations and rows summed over all statements; Index is -1
+	Slowest    int             // Index of the statement with the longest duration, or -1 if there are none
+}
+
+// Timings returns a [TimingReport] for results, the results of the statements
+// of a query as returned by [Client.RawQuery]. Durations are the execution
+// times reported by the D1 API, which exclude network and queueing time.
+//
+// Example usage:
+//
+//	results, err := client.RawQuery(ctx, dbID, batchSQL)
+//	if err != nil {
+//	    // handle error
+//	}
+//	fmt.Print(cfd1.Timings(results))
+func Timings(results []RawQueryResult) TimingReport {
+	report := TimingReport{
+		Statements: make([]StatementTiming, len(results)),
+		Total:      StatementTiming{Index: -1},
+		Slowest:    -1,
+	}
+	for i, r := range results {
+		st := StatementTiming{
+			Index:       i,
+			Duration:    r.Meta.elapsed(),
+			RowsRead:    r.Meta.RowsRead,
+			RowsWritten: r.Meta.RowsWritten,
+		}
+		report.Statements[i] = st
+		report.Total.Duration += st.Duration
+		report.Total.RowsRead += st.RowsRead
+		report.Total.RowsWritten += st.RowsWritten
+		if report.Slowest < 0 || st.Duration > report.Statements[report.Slowest].Duration {
+			report.Slowest = i
+		}
+	}
+	return report
+}
+
+// String formats the report as a table with one line per statement, followed
+// by the totals. The slowest statement is marked with an asterisk.
+func (r TimingReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-6s %12s %10s %10s\n", "stmt", "duration", "read", "written")
+	for _, st := range r.Statements {
+		mark := ""
+		if st.Index == r.Slowest {
+			mark = " *"
+		}
+		fmt.Fprintf(&sb, "%-6d %12s %10d %10d%s\n", st.Index, st.Duration.String(), st.RowsRead, st.RowsWritten, mark)
+	}
+	fmt.Fprintf(&sb, "%-6s %12s %10d %10d\n", "total", r.Total.Duration.String(), r.Total.RowsRead, r.Total.RowsWritten)
+	return sb.String()
+}
@@ -0,0 +1,45 @@
+package cfd1
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimings(t *testing.T) {
+	results := make([]RawQueryResult, 3)
+	for i, m := range []QueryMeta{
+		{Duration: 0.5, RowsRead: 1},
+		{Duration: 12.25, RowsRead: 1000, RowsWritten: 2},
+		{Duration: 3, RowsWritten: 5},
+	} {
+		results[i].Meta = m
+	}
+
+	got := Timings(results)
+	want := TimingReport{
+		Statements: []StatementTiming{
+			{0, 500 * time.Microsecond, 1, 0},
+			{1, 12250 * time.Microsecond, 1000, 2},
+			{2, 3 * time.Millisecond, 0, 5},
+		},
+		Total:   StatementTiming{-1, 15750 * time.Microsecond, 1001, 7},
+		Slowest: 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Timings() = %+v, want %+v", got, want)
+	}
+
+	wantStr := "stmt       duration       read    written\n" +
+		"0             500µs          1          0\n" +
+		"1           12.25ms       1000          2 *\n" +
+		"2               3ms          0          5\n" +
+		"total       15.75ms       1001          7\n"
+	if s := got.String(); s != wantStr {
+		t.Errorf("String() =\n%s\nwant\n%s", s, wantStr)
+	}
+
+	if empty := Timings(nil); empty.Slowest != -1 || len(empty.Statements) != 0 {
+		t.Errorf("Timings(nil) = %+v", empty)
+	}
+}
@@ -0,0 +1,122 @@
+package cfd1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// txRetryBaseDelay is the delay before the first retry of a transaction, which
+// doubles with each subsequent retry.
+const txRetryBaseDelay = 100 * time.Millisecond
+
+// TxBatch collects statements to be executed as a single transaction. The D1
+// API does not support interactive transactions, so statements added with
+// [TxBatch.Exec] are buffered, and sent together in one request, wrapped in
+// BEGIN TRANSACTION and COMMIT, after the transaction function returns.
+// Statements cannot observe each other's results; reads needed to decide what
+// to write should be made through the [Handle] before the statements are added.
+type TxBatch struct {
+	statements []string
+	params     []any
+}
+
+// Exec adds a statement to the transaction. Statements should use anonymous ?
+// placeholders, as the parameters of all statements are combined into a single
+// list in the order the statements were added.
+func (tx *TxBatch) Exec(sql string, params ...any) {
+	tx.statements = append(tx.statements, strings.TrimRight(strings.TrimSpace(sql), ";"))
+	tx.params = append(tx.params, params...)
+}
+
+// sql returns the transaction's statements as a single query.
+func (tx *TxBatch) sql() string {
+	return "BEGIN TRANSACTION;\n" + strings.Join(tx.statements, ";\n") + ";\nCOMMIT;"
+}
+
+// Tx runs fn to collect statements into a transaction, and then executes them
+// in a single request, wrapped in BEGIN TRANSACTION and COMMIT, so that either
+// all of their changes are kept or, if one fails, none are. If fn returns an
+// error, nothing is sent and the error is returned. If fn adds no statements,
+// nothing is sent and Tx returns nil.
+//
+// This is a single round trip, not an interactive transaction: the statements
+// are only sent after fn returns, so fn cannot see their effects, and queries
+// it makes through the handle run outside the transaction. See
+// [Handle.TransactionWithRetry] to retry transactions that fail because the
+// database is busy.
+//
+// Example usage:
+//
+//	err := h.Tx(ctx, func(tx *cfd1.TxBatch) error {
+//	    tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", amount, from)
+//	    tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", amount, to)
+//	    return nil
+//	})
+func (h *Handle) Tx(ctx context.Context, fn func(*TxBatch) error) error {
+	return h.TransactionWithRetry(ctx, 1, fn)
+}
+
+// TransactionWithRetry runs fn to build a transaction, and executes it. If the
+// transaction fails because the database is busy or locked (SQLITE_BUSY or
+// SQLITE_LOCKED), fn is run again to build a fresh transaction, which is then
+// executed, up to maxAttempts times in total. Since fn runs again on each
+// attempt, any reads it makes observe the latest state of the database, giving
+// optimistic-concurrency retries. Retries are delayed with exponential backoff,
+// starting at 100ms.
+//
+// If fn returns an error, the transaction is abandoned without executing any
+// statements, and the error is returned. If the context is canceled while
+// waiting to retry, the context's error is returned.
+//
+// Example usage:
+//
+//	err := h.TransactionWithRetry(ctx, 5, func(tx *cfd1.TxBatch) error {
+//	    var balance int
+//	    if err := h.QueryRowScan(ctx, "SELECT balance FROM accounts WHERE id = ?",
+//	        []any{id}, &balance); err != nil {
+//	        return err
+//	    }
+//	    tx.Exec("UPDATE accounts SET balance = ? WHERE id = ?", balance-amount, id)
+//	    tx.Exec("INSERT INTO ledger (account_id, amount) VALUES (?, ?)", id, -amount)
+//	    return nil
+//	})
+func (h *Handle) TransactionWithRetry(ctx context.Context, maxAttempts int, fn func(*TxBatch) error) error {
+	delay := txRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		tx := &TxBatch{}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if len(tx.statements) == 0 {
+			return nil
+		}
+
+		err := h.Execute(ctx, tx.sql(), tx.params...)
+		if err == nil || attempt >= maxAttempts || !isBusyError(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			// Reported as ErrCanceled, like a request canceled while it is sent
+			return canceledError(http.MethodPost, fmt.Sprintf("/database/%s/raw", h.dbID), ctx.Err())
+		}
+	}
+}
+
+// isBusyError reports whether err indicates that the database was busy or
+// locked, such that retrying the operation may succeed.
+func isBusyError(err error) bool {
+	var sqliteErr *SQLiteError
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return strings.HasPrefix(sqliteErr.SQLiteCode, "SQLITE_BUSY") ||
+		strings.HasPrefix(sqliteErr.SQLiteCode, "SQLITE_LOCKED")
+}
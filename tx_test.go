@@ -0,0 +1,122 @@
+package cfd1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestTransactionWithRetry(t *testing.T) {
+	var requests []rawQueryRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		if len(requests) < 3 {
+			writeAPIError(w, http.StatusBadRequest, 7500, "database is locked: SQLITE_BUSY")
+			return
+		}
+		writeAPIResult(w, []QueryResult{{Success: true}}, nil)
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	var runs int
+	err := h.TransactionWithRetry(context.Background(), 5, func(tx *TxBatch) error {
+		runs++
+		tx.Exec("UPDATE accounts SET balance = ? WHERE id = ?;", runs, 1)
+		tx.Exec("INSERT INTO ledger (account_id) VALUES (?)", 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runs != 3 {
+		t.Errorf("unexpected number of runs: got %d, want 3", runs)
+	}
+
+	last := requests[len(requests)-1]
+	wantSQL := "BEGIN TRANSACTION;\nUPDATE accounts SET balance = ? WHERE id = ?;\nINSERT INTO ledger (account_id) VALUES (?);\nCOMMIT;"
+	if last.SQL != wantSQL {
+		t.Errorf("unexpected SQL: got %q, want %q", last.SQL, wantSQL)
+	}
+	if want := []any{3.0, 1.0, 1.0}; !reflect.DeepEqual(last.Params, want) {
+		t.Errorf("unexpected params: got %v, want %v", last.Params, want)
+	}
+
+	// Errors other than SQLITE_BUSY and SQLITE_LOCKED are not retried
+	var attempts int
+	client = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		writeAPIError(w, http.StatusBadRequest, 7500, "no such table: missing: SQLITE_ERROR")
+	})
+	h, _ = client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+	err = h.TransactionWithRetry(context.Background(), 5, func(tx *TxBatch) error {
+		tx.Exec("DELETE FROM missing")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if attempts != 1 {
+		t.Errorf("unexpected number of attempts: got %d, want 1", attempts)
+	}
+}
+
+func TestTransactionWithRetryCanceled(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusBadRequest, 7500, "database is locked: SQLITE_BUSY")
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	// The context ends during the wait before the first retry
+	ctx, cancel := context.WithTimeout(context.Background(), txRetryBaseDelay/4)
+	defer cancel()
+	err := h.TransactionWithRetry(ctx, 5, func(tx *TxBatch) error {
+		tx.Exec("UPDATE accounts SET balance = 0")
+		return nil
+	})
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected ErrCanceled wrapping context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestHandleTx(t *testing.T) {
+	var requests []rawQueryRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rawQueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		writeAPIError(w, http.StatusBadRequest, 7500, "database is locked: SQLITE_BUSY")
+	})
+	h, _ := client.GetHandle(context.Background(), "e4e4e4e4-4555-4777-b222-1a2b3c4d5e6f")
+
+	// An error from fn sends nothing
+	errAbort := errors.New("abort")
+	err := h.Tx(context.Background(), func(tx *TxBatch) error {
+		tx.Exec("DELETE FROM t")
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) || len(requests) != 0 {
+		t.Fatalf("expected errAbort and no requests, got %v and %d requests", err, len(requests))
+	}
+
+	// Busy errors are not retried
+	err = h.Tx(context.Background(), func(tx *TxBatch) error {
+		tx.Exec("INSERT INTO t VALUES (?)", 1)
+		tx.Exec("INSERT INTO t VALUES (?)", 2)
+		return nil
+	})
+	if !isBusyError(err) {
+		t.Errorf("expected busy error, got %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("unexpected number of requests: got %d, want 1", len(requests))
+	}
+	wantSQL := "BEGIN TRANSACTION;\nINSERT INTO t VALUES (?);\nINSERT INTO t VALUES (?);\nCOMMIT;"
+	if requests[0].SQL != wantSQL {
+		t.Errorf("unexpected SQL: got %q, want %q", requests[0].SQL, wantSQL)
+	}
+}